@@ -0,0 +1,153 @@
+package btools
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ripemd160"
+)
+
+type AddressType int
+
+const (
+	// AddressP2PKH is a legacy pay-to-public-key-hash address.
+	AddressP2PKH AddressType = iota + 1
+
+	// AddressP2SHP2WPKH is a P2WPKH script nested in a P2SH address.
+	AddressP2SHP2WPKH
+
+	// AddressP2WPKH is a native SegWit v0 address.
+	AddressP2WPKH
+
+	// AddressP2TR is a Taproot address with the BIP86 key-path-only tweak.
+	AddressP2TR
+)
+
+// AddressTypes lists the supported address types.
+var AddressTypes = []AddressType{
+	AddressP2PKH,
+	AddressP2SHP2WPKH,
+	AddressP2WPKH,
+	AddressP2TR,
+}
+
+func (t AddressType) String() string {
+	switch t {
+	case AddressP2PKH:
+		return "p2pkh"
+	case AddressP2SHP2WPKH:
+		return "p2sh-p2wpkh"
+	case AddressP2WPKH:
+		return "p2wpkh"
+	case AddressP2TR:
+		return "p2tr"
+	}
+
+	return fmt.Sprintf("AddressType(%d)", int(t))
+}
+
+// ParseAddressType parses the names returned by AddressType.String.
+func ParseAddressType(s string) (AddressType, error) {
+	for _, t := range AddressTypes {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown address type: %s", s)
+}
+
+// AddressTypeForPurpose returns the address type used by the BIP44, BIP49,
+// BIP84 and BIP86 derivation schemes.
+func AddressTypeForPurpose(purpose uint32) (AddressType, error) {
+	switch purpose {
+	case 44:
+		return AddressP2PKH, nil
+	case 49:
+		return AddressP2SHP2WPKH, nil
+	case 84:
+		return AddressP2WPKH, nil
+	case 86:
+		return AddressP2TR, nil
+	}
+
+	return 0, fmt.Errorf("no address type for purpose %d", purpose)
+}
+
+// Address returns the address of type t for the public key pub on net.
+func Address(pub Point, t AddressType, net Network) (string, error) {
+	comp, err := Secp256k1Compressed(pub)
+	if err != nil {
+		return "", err
+	}
+
+	keyHash := hash160(comp)
+
+	switch t {
+	case AddressP2PKH:
+		return Base58Check(append([]byte{net.P2PKHVersion}, keyHash...)), nil
+
+	case AddressP2SHP2WPKH:
+		redeemScript := append([]byte{0x00, 0x14}, keyHash...)
+		return Base58Check(append([]byte{net.P2SHVersion}, hash160(redeemScript)...)), nil
+
+	case AddressP2WPKH:
+		return EncodeSegWitAddress(net.Bech32HRP, 0, keyHash)
+
+	case AddressP2TR:
+		output, err := TaprootOutputKey(pub)
+		if err != nil {
+			return "", err
+		}
+
+		return EncodeSegWitAddress(net.Bech32HRP, 1, output.X.FillBytes(make([]byte, 32)))
+	}
+
+	return "", fmt.Errorf("unknown address type: %d", int(t))
+}
+
+func (xpub XPubKey) Address(t AddressType, net Network) (string, error) {
+	return Address(xpub.PublicKey, t, net)
+}
+
+// TaprootOutputKey tweaks the internal key with an empty script tree as
+// specified in BIP86: Q = P + int(hashTapTweak(x(P)))G, with P taken with
+// an even y.
+func TaprootOutputKey(internal Point) (Point, error) {
+	if internal.IsInfinity() {
+		return Point{}, fmt.Errorf("invalid internal key: %w", ErrPointAtInfinity)
+	}
+
+	if !internal.IsOnCurve() {
+		return Point{}, fmt.Errorf("invalid internal key: point is not on the curve")
+	}
+
+	if internal.Y.Bit(0) == 1 {
+		var err error
+		internal, err = internal.Inverse()
+		if err != nil {
+			return Point{}, err
+		}
+	}
+
+	tweak := big.NewInt(0).SetBytes(TaggedHash("TapTweak", internal.X.FillBytes(make([]byte, 32))))
+	if tweak.Cmp(secp256k1Order) >= 0 {
+		return Point{}, fmt.Errorf("invalid taproot tweak")
+	}
+
+	output := Secp256k1Add(internal, Secp256k1Pub(tweak))
+	if output.IsInfinity() {
+		return Point{}, fmt.Errorf("invalid taproot output key: %w", ErrPointAtInfinity)
+	}
+
+	return output, nil
+}
+
+func hash160(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	ripe := ripemd160.New()
+	ripe.Write(hash[:])
+
+	return ripe.Sum(nil)
+}
@@ -0,0 +1,78 @@
+package btools
+
+import (
+	"strings"
+	"testing"
+)
+
+// addresses of the "abandon (x11) about" wallet from BIP44, BIP49, BIP84 and
+// BIP86
+var addressVectors = []struct {
+	path    string
+	t       AddressType
+	net     Network
+	address string
+}{
+	{"m/44'/0'/0'/0/0", AddressP2PKH, Mainnet, "1LqBGSKuX5yYUonjxT5qGfpUsXKYYWeabA"},
+	{"m/49'/0'/0'/0/0", AddressP2SHP2WPKH, Mainnet, "37VucYSaXLCAsxYyAPfbSi9eh4iEcbShgf"},
+	{"m/49'/1'/0'/0/0", AddressP2SHP2WPKH, Testnet3, "2Mww8dCYPUpKHofjgcXcBCEGmniw9CoaiD2"},
+	{"m/84'/0'/0'/0/0", AddressP2WPKH, Mainnet, "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"},
+	{"m/84'/0'/0'/0/1", AddressP2WPKH, Mainnet, "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"},
+	{"m/84'/0'/0'/1/0", AddressP2WPKH, Mainnet, "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el"},
+	{"m/86'/0'/0'/0/0", AddressP2TR, Mainnet, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"},
+	{"m/86'/0'/0'/0/1", AddressP2TR, Mainnet, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh"},
+	{"m/86'/0'/0'/1/0", AddressP2TR, Mainnet, "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7"},
+}
+
+func TestAddress(t *testing.T) {
+	seed, err := NewSeed(strings.Fields(recoveryMnemonic), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master, err := MasterPrivateKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range addressVectors {
+		path, err := ParseDerivationPath(v.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := master.DerivePath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := key.XPubKey().Address(v.t, v.net)
+		if err != nil || got != v.address {
+			t.Errorf("%s address at %s = %s, %v, want %s", v.t, v.path, got, err, v.address)
+		}
+
+		if purposeType, err := AddressTypeForPurpose(path[0] - HardenedKeyStart); err != nil || purposeType != v.t {
+			t.Errorf("AddressTypeForPurpose(%s) = %s, %v, want %s", v.path, purposeType, err, v.t)
+		}
+	}
+
+	if _, err := Address(Secp256k1Pub(benchmarkKey), AddressType(0), Mainnet); err == nil {
+		t.Errorf("Address accepted an unknown type")
+	}
+
+	if _, err := AddressTypeForPurpose(45); err == nil {
+		t.Errorf("AddressTypeForPurpose accepted purpose 45")
+	}
+}
+
+func TestParseAddressType(t *testing.T) {
+	for _, addrType := range AddressTypes {
+		if got, err := ParseAddressType(addrType.String()); err != nil || got != addrType {
+			t.Errorf("ParseAddressType(%s) = %s, %v", addrType, got, err)
+		}
+	}
+
+	if _, err := ParseAddressType("p2wsh"); err == nil {
+		t.Errorf("ParseAddressType accepted p2wsh")
+	}
+}
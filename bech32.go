@@ -0,0 +1,359 @@
+package btools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Bech32Encoding int
+
+const (
+	// Bech32 is the encoding defined in BIP173, used by SegWit v0 addresses.
+	Bech32 Bech32Encoding = iota + 1
+
+	// Bech32m is the encoding defined in BIP350, used by SegWit v1+
+	// addresses.
+	Bech32m
+)
+
+func (enc Bech32Encoding) String() string {
+	switch enc {
+	case Bech32:
+		return "bech32"
+	case Bech32m:
+		return "bech32m"
+	}
+
+	return fmt.Sprintf("Bech32Encoding(%d)", int(enc))
+}
+
+func (enc Bech32Encoding) constant() uint32 {
+	if enc == Bech32m {
+		return 0x2BC830A3
+	}
+
+	return 1
+}
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+const bech32MaxLength = 90
+
+var (
+	ErrBech32MixedCase = errors.New("bech32 string has mixed case")
+	ErrBech32Checksum  = errors.New("invalid bech32 checksum")
+)
+
+// Bech32Error describes an invalid bech32 string. Positions holds the indexes
+// of the characters likely to be wrong, when they can be located.
+type Bech32Error struct {
+	Err       error
+	Reason    string
+	Positions []int
+}
+
+func (e Bech32Error) Error() string {
+	msg := e.Reason
+	if e.Err != nil {
+		msg = e.Err.Error()
+		if e.Reason != "" {
+			msg += ": " + e.Reason
+		}
+	}
+
+	if len(e.Positions) > 0 {
+		positions := make([]string, len(e.Positions))
+		for i, p := range e.Positions {
+			positions[i] = strconv.Itoa(p)
+		}
+		msg += fmt.Sprintf(" (likely error at position %s)", strings.Join(positions, ", "))
+	}
+
+	return msg
+}
+
+func (e Bech32Error) Unwrap() error {
+	return e.Err
+}
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3B6A57B2, 0x26508E6D, 0x1EA119FA, 0x3D4233DD, 0x2A1462B3}
+
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1FFFFFF)<<5 ^ uint32(v)
+		for i := range 5 {
+			if (b>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	result := make([]byte, 0, len(hrp)*2+1)
+	for i := range len(hrp) {
+		result = append(result, hrp[i]>>5)
+	}
+
+	result = append(result, 0)
+	for i := range len(hrp) {
+		result = append(result, hrp[i]&31)
+	}
+
+	return result
+}
+
+func bech32Checksum(hrp string, data []byte, enc Bech32Encoding) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	values = append(values, 0, 0, 0, 0, 0, 0)
+	polymod := bech32Polymod(values) ^ enc.constant()
+
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(polymod>>(5*(5-i))) & 31
+	}
+
+	return checksum
+}
+
+func validateBech32HRP(hrp string) error {
+	if len(hrp) < 1 || len(hrp) > 83 {
+		return Bech32Error{Reason: fmt.Sprintf("invalid human-readable part length: %d", len(hrp))}
+	}
+
+	for i := range len(hrp) {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return Bech32Error{Reason: "invalid character in human-readable part", Positions: []int{i}}
+		}
+	}
+
+	return nil
+}
+
+// Bech32Encode encodes the 5-bit groups in data with the given human-readable
+// part. The result is in lower case and at most 90 characters long.
+func Bech32Encode(hrp string, data []byte, enc Bech32Encoding) (string, error) {
+	if enc != Bech32 && enc != Bech32m {
+		return "", fmt.Errorf("unknown bech32 encoding: %d", int(enc))
+	}
+
+	if len(hrp)+1+len(data)+6 > bech32MaxLength {
+		return "", Bech32Error{Reason: fmt.Sprintf("string is longer than %d characters", bech32MaxLength)}
+	}
+
+	if err := validateBech32HRP(hrp); err != nil {
+		return "", err
+	}
+
+	if strings.ToLower(hrp) != hrp && strings.ToUpper(hrp) != hrp {
+		return "", ErrBech32MixedCase
+	}
+	hrp = strings.ToLower(hrp)
+
+	builder := strings.Builder{}
+	builder.WriteString(hrp)
+	builder.WriteByte('1')
+	for _, d := range data {
+		if d > 31 {
+			return "", fmt.Errorf("invalid 5-bit group: %d", d)
+		}
+		builder.WriteByte(bech32Charset[d])
+	}
+
+	for _, d := range bech32Checksum(hrp, data, enc) {
+		builder.WriteByte(bech32Charset[d])
+	}
+
+	return builder.String(), nil
+}
+
+// Bech32Decode decodes a bech32 or bech32m string of at most 90 characters
+// and returns its human-readable part, the 5-bit groups of its data part
+// without the checksum, and the encoding it uses.
+func Bech32Decode(s string) (string, []byte, Bech32Encoding, error) {
+	if len(s) > bech32MaxLength {
+		return "", nil, 0, Bech32Error{Reason: fmt.Sprintf("string is longer than %d characters", bech32MaxLength)}
+	}
+
+	return Bech32DecodeNoLimit(s)
+}
+
+// Bech32DecodeNoLimit is like Bech32Decode but accepts strings of any
+// length, as used for example by Lightning invoices.
+func Bech32DecodeNoLimit(s string) (string, []byte, Bech32Encoding, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, 0, ErrBech32MixedCase
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 0 {
+		return "", nil, 0, Bech32Error{Reason: "missing separator"}
+	}
+
+	if sep+7 > len(s) {
+		return "", nil, 0, Bech32Error{Reason: "checksum is too short", Positions: []int{sep}}
+	}
+
+	hrp := s[:sep]
+	if err := validateBech32HRP(hrp); err != nil {
+		return "", nil, 0, err
+	}
+
+	data := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+		if d < 0 {
+			return "", nil, 0, Bech32Error{Reason: fmt.Sprintf("invalid character %q", s[i]), Positions: []int{i}}
+		}
+		data = append(data, byte(d))
+	}
+
+	polymod := bech32Polymod(append(bech32HRPExpand(hrp), data...))
+	var enc Bech32Encoding
+	switch polymod {
+	case Bech32.constant():
+		enc = Bech32
+	case Bech32m.constant():
+		enc = Bech32m
+	default:
+		return "", nil, 0, Bech32Error{Err: ErrBech32Checksum, Positions: locateBech32Errors(hrp, data, sep+1)}
+	}
+
+	return hrp, data[:len(data)-6], enc, nil
+}
+
+// locateBech32Errors returns the positions of the characters that, replaced
+// by another symbol, would make the checksum valid under either encoding.
+func locateBech32Errors(hrp string, data []byte, offset int) []int {
+	values := append(bech32HRPExpand(hrp), data...)
+	start := len(values) - len(data)
+
+	positions := []int{}
+	for i := range data {
+		original := values[start+i]
+		for d := range byte(32) {
+			if d == original {
+				continue
+			}
+
+			values[start+i] = d
+			polymod := bech32Polymod(values)
+			if polymod == Bech32.constant() || polymod == Bech32m.constant() {
+				positions = append(positions, offset+i)
+				break
+			}
+		}
+		values[start+i] = original
+	}
+
+	return positions
+}
+
+// ConvertBits regroups data from groups of fromBits bits into groups of toBits
+// bits. With pad, the last group is padded with zeros; without it, leftover
+// bits must be zero padding of less than fromBits bits.
+func ConvertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	acc := uint32(0)
+	bits := uint(0)
+	maxValue := uint32(1)<<toBits - 1
+
+	result := []byte{}
+	for _, d := range data {
+		if uint32(d)>>fromBits != 0 {
+			return nil, fmt.Errorf("invalid %d-bit group: %d", fromBits, d)
+		}
+
+		acc = acc<<fromBits | uint32(d)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			result = append(result, byte((acc>>bits)&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			result = append(result, byte((acc<<(toBits-bits))&maxValue))
+		}
+	} else if bits >= fromBits || (acc<<(toBits-bits))&maxValue != 0 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+
+	return result, nil
+}
+
+// EncodeSegWitAddress encodes a witness program as a SegWit address, using
+// bech32 for version 0 and bech32m for later versions.
+func EncodeSegWitAddress(hrp string, version byte, program []byte) (string, error) {
+	if err := validateWitnessProgram(version, program); err != nil {
+		return "", err
+	}
+
+	enc := Bech32m
+	if version == 0 {
+		enc = Bech32
+	}
+
+	data, err := ConvertBits(program, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	return Bech32Encode(hrp, append([]byte{version}, data...), enc)
+}
+
+// DecodeSegWitAddress decodes a SegWit address for the network with the
+// given human-readable part and returns its witness version and program.
+func DecodeSegWitAddress(hrp, address string) (byte, []byte, error) {
+	addrHRP, data, enc, err := Bech32Decode(address)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if addrHRP != strings.ToLower(hrp) {
+		return 0, nil, fmt.Errorf("invalid human-readable part: expected %q, got %q", hrp, addrHRP)
+	}
+
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("empty witness program")
+	}
+
+	version := data[0]
+	if version == 0 && enc != Bech32 || version != 0 && enc != Bech32m {
+		return 0, nil, fmt.Errorf("invalid encoding %s for witness version %d", enc, version)
+	}
+
+	program, err := ConvertBits(data[1:], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := validateWitnessProgram(version, program); err != nil {
+		return 0, nil, err
+	}
+
+	return version, program, nil
+}
+
+func validateWitnessProgram(version byte, program []byte) error {
+	if version > 16 {
+		return fmt.Errorf("invalid witness version: %d", version)
+	}
+
+	if len(program) < 2 || len(program) > 40 {
+		return fmt.Errorf("invalid witness program length: %d", len(program))
+	}
+
+	if version == 0 && len(program) != 20 && len(program) != 32 {
+		return fmt.Errorf("invalid witness program length for version 0: %d", len(program))
+	}
+
+	return nil
+}
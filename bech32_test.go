@@ -0,0 +1,204 @@
+package btools
+
+import (
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// the test vectors of BIP173 and BIP350
+var bech32Valid = []struct {
+	s   string
+	enc Bech32Encoding
+}{
+	{"A12UEL5L", Bech32},
+	{"a12uel5l", Bech32},
+	{"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs", Bech32},
+	{"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw", Bech32},
+	{"11qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqc8247j", Bech32},
+	{"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w", Bech32},
+	{"?1ezyfcl", Bech32},
+	{"A1LQFN3A", Bech32m},
+	{"a1lqfn3a", Bech32m},
+	{"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6", Bech32m},
+	{"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx", Bech32m},
+	{"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8", Bech32m},
+	{"split1checkupstagehandshakeupstreamerranterredcaperredlc445v", Bech32m},
+	{"?1v759aa", Bech32m},
+}
+
+var bech32Invalid = []struct {
+	s      string
+	reason string
+}{
+	{"\x201nwldj5", "HRP character out of range"},
+	{"\x7f1axkwrx", "HRP character out of range"},
+	{"\x801eym55h", "HRP character out of range"},
+	{"an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", "overall max length exceeded"},
+	{"pzry9x0s0muk", "no separator character"},
+	{"1pzry9x0s0muk", "empty HRP"},
+	{"x1b4n0q5v", "invalid data character"},
+	{"li1dgmt3", "too short checksum"},
+	{"de1lg7wt\xff", "invalid character in checksum"},
+	{"A1G7SGD8", "checksum calculated with uppercase form of HRP"},
+	{"10a06t8", "empty HRP"},
+	{"1qzzfhee", "empty HRP"},
+	{"\x201xj0phk", "HRP character out of range"},
+	{"\x7f1g6xzxy", "HRP character out of range"},
+	{"\x801vctc34", "HRP character out of range"},
+	{"an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4", "overall max length exceeded"},
+	{"qyrz8wqd2c9m", "no separator character"},
+	{"1qyrz8wqd2c9m", "empty HRP"},
+	{"y1b0jsk6g", "invalid data character"},
+	{"lt1igcx5c0", "invalid data character"},
+	{"in1muywd", "too short checksum"},
+	{"mm1crxm3i", "invalid character in checksum"},
+	{"au1s5cgom", "invalid character in checksum"},
+	{"M1VUXWEZ", "checksum calculated with uppercase form of HRP"},
+	{"16plkw9", "empty HRP"},
+	{"1p2gdwpf", "empty HRP"},
+}
+
+var segWitValid = []struct {
+	address      string
+	scriptPubKey string
+}{
+	{"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7", "00201863143c14c5166804bd19203356da136c985678cd4d27a1b8c6329604903262"},
+	{"bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y", "5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6"},
+	{"BC1SW50QGDZ25J", "6002751e"},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvaxxpcs", "5210751e76e8199196d454941c45d1b3a323"},
+	{"tb1qqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesrxh6hy", "0020000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"tb1pqqqqp399et2xygdj5xreqhjjvcmzhxw4aywxecjdzew6hylgvsesf3hn0c", "5120000000c4a5cad46221b2a187905e5266362b99d5e91c6ce24d165dab93e86433"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqzk5jj0", "512079be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"},
+}
+
+var segWitInvalid = []struct {
+	address string
+	reason  string
+}{
+	{"tc1qw508d6qejxtdg4y5r3zarvary0c5xw7kg3g4ty", "invalid human-readable part"},
+	{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "invalid checksum"},
+	{"BC13W508D6QEJXTDG4Y5R3ZARVARY0C5XW7KN40WF2", "invalid witness version"},
+	{"bc1rw5uspcuh", "invalid program length"},
+	{"bc10w508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kw5rljs90", "invalid program length"},
+	{"BC1QR508D6QEJXTDG4Y5R3ZARVARYV98GJ9P", "invalid program length for witness version 0"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7", "mixed case"},
+	{"bc1zw508d6qejxtdg4y5r3zarvaryvqyzf3du", "zero padding of more than 4 bits"},
+	{"tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3pjxtptv", "non-zero padding in 8-to-5 conversion"},
+	{"bc1gmk9yu", "empty data section"},
+	{"tc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq5zuyut", "invalid human-readable part"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqh2y7hd", "bech32 instead of bech32m"},
+	{"tb1z0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vqglt7rf", "bech32 instead of bech32m"},
+	{"BC1S0XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ54WELL", "bech32 instead of bech32m"},
+	{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "bech32m instead of bech32"},
+	{"tb1q0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq24jc47", "bech32m instead of bech32"},
+	{"bc1p38j9r5y49hruaue7wxjce0updqjuyyx0kh56v8s25huc6995vvpql3jow4", "invalid character in checksum"},
+	{"BC130XLXVLHEMJA6C4DQV22UAPCTQUPFHLXM9H8Z3K2E72Q4K9HCZ7VQ7ZWS8R", "invalid witness version"},
+	{"bc1pw5dgrnzv", "invalid program length (1 byte)"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v8n0nx0muaewav253zgeav", "invalid program length (41 bytes)"},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vq47Zagq", "mixed case"},
+	{"bc1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7v07qwwzcrf", "zero padding of more than 4 bits"},
+	{"tb1p0xlxvlhemja6c4dqv22uapctqupfhlxm9h8z3k2e72q4k9hcz7vpggkg4j", "non-zero padding in 8-to-5 conversion"},
+}
+
+func TestBech32(t *testing.T) {
+	for _, v := range bech32Valid {
+		hrp, data, enc, err := Bech32Decode(v.s)
+		if err != nil || enc != v.enc {
+			t.Errorf("Bech32Decode(%s) = %s, %v, want %s", v.s, enc, err, v.enc)
+			continue
+		}
+
+		if got, err := Bech32Encode(hrp, data, enc); err != nil || got != strings.ToLower(v.s) {
+			t.Errorf("Bech32Encode(%s, %x, %s) = %s, %v, want %s", hrp, data, enc, got, err, strings.ToLower(v.s))
+		}
+	}
+
+	for _, v := range bech32Invalid {
+		if _, _, _, err := Bech32Decode(v.s); err == nil {
+			t.Errorf("Bech32Decode(%q) succeeded: %s", v.s, v.reason)
+		}
+	}
+
+	if _, _, _, err := Bech32Decode("tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sL5k7"); !errors.Is(err, ErrBech32MixedCase) {
+		t.Errorf("Bech32Decode of a mixed case string returned %v, want ErrBech32MixedCase", err)
+	}
+
+	if _, err := Bech32Encode("Bc", []byte{0}, Bech32); !errors.Is(err, ErrBech32MixedCase) {
+		t.Errorf("Bech32Encode of a mixed case human-readable part returned %v, want ErrBech32MixedCase", err)
+	}
+
+	// 83 characters of human-readable part leave no room for data
+	hrp := strings.Repeat("a", 83)
+	if _, err := Bech32Encode(hrp, nil, Bech32); err != nil {
+		t.Errorf("Bech32Encode of 90 characters: %v", err)
+	}
+
+	if _, err := Bech32Encode(hrp, []byte{0}, Bech32); err == nil {
+		t.Errorf("Bech32Encode of 91 characters succeeded")
+	}
+
+	if _, err := Bech32Encode("bc", make([]byte, 82), Bech32m); err == nil {
+		t.Errorf("Bech32Encode of 91 characters succeeded")
+	}
+}
+
+func TestBech32ErrorPositions(t *testing.T) {
+	valid := "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+
+	for i := strings.IndexByte(valid, '1') + 1; i < len(valid); i++ {
+		for _, c := range []byte(bech32Charset) {
+			if c == valid[i] {
+				continue
+			}
+
+			s := valid[:i] + string(c) + valid[i+1:]
+
+			var bech32Err Bech32Error
+			_, _, _, err := Bech32Decode(s)
+			if !errors.As(err, &bech32Err) || !errors.Is(err, ErrBech32Checksum) {
+				t.Fatalf("Bech32Decode(%s) returned %v, want a checksum error", s, err)
+			}
+
+			if !slices.Contains(bech32Err.Positions, i) {
+				t.Errorf("Bech32Decode(%s) located errors at %v, want %d", s, bech32Err.Positions, i)
+			}
+		}
+	}
+
+	var bech32Err Bech32Error
+	if _, _, _, err := Bech32Decode("x1b4n0q5v"); !errors.As(err, &bech32Err) || !slices.Equal(bech32Err.Positions, []int{2}) {
+		t.Errorf("Bech32Decode(x1b4n0q5v) returned %v, want an error at position 2", err)
+	}
+}
+
+func TestSegWitAddress(t *testing.T) {
+	for _, v := range segWitValid {
+		hrp := strings.ToLower(v.address[:2])
+
+		version, program, err := DecodeSegWitAddress(hrp, v.address)
+		if err != nil {
+			t.Errorf("DecodeSegWitAddress(%s): %v", v.address, err)
+			continue
+		}
+
+		if got := hex.EncodeToString(witnessScript(version, program)); got != v.scriptPubKey {
+			t.Errorf("DecodeSegWitAddress(%s) gives the script %s, want %s", v.address, got, v.scriptPubKey)
+		}
+
+		if got, err := EncodeSegWitAddress(hrp, version, program); err != nil || got != strings.ToLower(v.address) {
+			t.Errorf("EncodeSegWitAddress(%s, %d, %x) = %s, %v", hrp, version, program, got, err)
+		}
+	}
+
+	for _, v := range segWitInvalid {
+		for _, hrp := range []string{"bc", "tb"} {
+			if _, _, err := DecodeSegWitAddress(hrp, v.address); err == nil {
+				t.Errorf("DecodeSegWitAddress(%s, %s) succeeded: %s", hrp, v.address, v.reason)
+			}
+		}
+	}
+}
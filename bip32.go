@@ -1,28 +1,30 @@
 package btools
 
 import (
+	"bytes"
 	"crypto/hmac"
-	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
 	"math/big"
-
-	"golang.org/x/crypto/ripemd160"
 )
 
-func Secp256k1Identifier(k *big.Int) []byte {
-	pub := Secp256k1Pub(k)
-	pubComp := Secp256k1Compressed(pub)
-	hash := sha256.Sum256(pubComp)
-	ripe := ripemd160.New()
-	ripe.Write(hash[:])
+func Secp256k1Identifier(k *big.Int) ([]byte, error) {
+	pub, err := Secp256k1Compressed(Secp256k1Pub(k))
+	if err != nil {
+		return nil, err
+	}
 
-	return ripe.Sum(nil)
+	return hash160(pub), nil
 }
 
-func Secp256k1Fingerprint(k *big.Int) []byte {
-	return Secp256k1Identifier(k)[:4]
+func Secp256k1Fingerprint(k *big.Int) ([]byte, error) {
+	id, err := Secp256k1Identifier(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return id[:4], nil
 }
 
 type XPrivKey struct {
@@ -30,6 +32,10 @@ type XPrivKey struct {
 	Depth  uint8
 	Index  uint32
 
+	// ParentFingerprint is used when Parent is not known, e.g. for keys
+	// loaded with ParseExtendedKey.
+	ParentFingerprint []byte
+
 	PrivateKey *big.Int
 	ChainCode  []byte
 }
@@ -38,67 +44,97 @@ func (xpriv XPrivKey) XPubKey() XPubKey {
 	pub := Secp256k1Pub(xpriv.PrivateKey)
 
 	return XPubKey{
-		PrivParent: xpriv.Parent,
-		Depth:      xpriv.Depth,
-		Index:      xpriv.Index,
-		ChainCode:  xpriv.ChainCode,
-		PublicKey:  pub,
+		PrivParent:        xpriv.Parent,
+		Depth:             xpriv.Depth,
+		Index:             xpriv.Index,
+		ParentFingerprint: xpriv.ParentFingerprint,
+		ChainCode:         xpriv.ChainCode,
+		PublicKey:         pub,
 	}
 }
 
-func (xpriv XPrivKey) Identifier() []byte {
-	pub := Secp256k1Pub(xpriv.PrivateKey)
-	pubComp := Secp256k1Compressed(pub)
-	hash := sha256.Sum256(pubComp)
-	ripe := ripemd160.New()
-	ripe.Write(hash[:])
+func (xpriv XPrivKey) Identifier() ([]byte, error) {
+	if err := xpriv.checkPrivateKey(); err != nil {
+		return nil, err
+	}
 
-	return ripe.Sum(nil)
+	return Secp256k1Identifier(xpriv.PrivateKey)
 }
 
-func (xpriv XPrivKey) Fingerprint() []byte {
-	return xpriv.Identifier()[:4]
+func (xpriv XPrivKey) Fingerprint() ([]byte, error) {
+	id, err := xpriv.Identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return id[:4], nil
 }
 
-func (xpriv XPrivKey) SerializeKey(mainnet bool) string {
+func (xpriv XPrivKey) SerializeKey(net Network) (string, error) {
+	return xpriv.SerializeKeyVersions(net.KeyVersions)
+}
+
+func (xpriv XPrivKey) SerializeKeyVersions(versions KeyVersions) (string, error) {
 	// TODO: validate that depth, parkbytes, i are consistent
-	bytes := []byte{}
-	if mainnet {
-		bytes = append(bytes, []byte{0x04, 0x88, 0xAD, 0xE4}...)
-	} else {
-		bytes = append(bytes, []byte{0x04, 0x35, 0x83, 0x94}...)
+	if err := xpriv.checkPrivateKey(); err != nil {
+		return "", err
 	}
 
-	bytes = append(bytes, byte(xpriv.Depth))
-
-	isMaster := xpriv.Parent == nil
-	if isMaster {
-		bytes = append(bytes, []byte{0x00, 0x00, 0x00, 0x00}...)
-	} else {
-		fingerprint := xpriv.Parent.Fingerprint()
-		bytes = append(bytes, fingerprint...)
+	parentFingerprint, err := xpriv.parentFingerprint()
+	if err != nil {
+		return "", err
 	}
 
+	bytes := append([]byte{}, versions.Private...)
+
+	bytes = append(bytes, byte(xpriv.Depth))
+	bytes = append(bytes, parentFingerprint...)
 	bytes = binary.BigEndian.AppendUint32(bytes, xpriv.Index)
 
 	bytes = append(bytes, xpriv.ChainCode...)
 
 	bytes = append(bytes, 0x00)
-	bytes = append(bytes, xpriv.PrivateKey.Bytes()...)
+	bytes = append(bytes, xpriv.PrivateKey.FillBytes(make([]byte, 32))...)
+
+	return Base58Check(bytes), nil
+}
 
-	return Base58Check(bytes)
+func (xpriv XPrivKey) parentFingerprint() ([]byte, error) {
+	if xpriv.Parent != nil {
+		return xpriv.Parent.Fingerprint()
+	}
+
+	if xpriv.ParentFingerprint != nil {
+		return xpriv.ParentFingerprint, nil
+	}
+
+	return []byte{0x00, 0x00, 0x00, 0x00}, nil
+}
+
+func (xpriv XPrivKey) checkPrivateKey() error {
+	if xpriv.PrivateKey == nil || xpriv.PrivateKey.Sign() <= 0 || xpriv.PrivateKey.Cmp(secp256k1Order) >= 0 {
+		return fmt.Errorf("invalid extended private key: private key is out of range")
+	}
+
+	return nil
 }
 
 func (xpriv XPrivKey) CKDpriv(i uint32) (XPrivKey, error) {
-	lim := uint32(1) << 31
+	if err := xpriv.checkPrivateKey(); err != nil {
+		return XPrivKey{}, err
+	}
+
 	var data []byte
-	if i >= lim {
+	if i >= HardenedKeyStart {
 		// If so (hardened child): let I = HMAC-SHA512(Key = cpar, Data = 0x00 || ser256(kpar) || ser32(i)). (Note: The 0x00 pads the private key to make it 33 bytes long.)
-		data = append([]byte{0x00}, xpriv.PrivateKey.Bytes()...)
+		data = append([]byte{0x00}, xpriv.PrivateKey.FillBytes(make([]byte, 32))...)
 	} else {
 		//If not (normal child): let I = HMAC-SHA512(Key = cpar, Data = serP(point(kpar)) || ser32(i)).
-		pub := Secp256k1Pub(xpriv.PrivateKey)
-		data = Secp256k1Compressed(pub)
+		pub, err := Secp256k1Compressed(Secp256k1Pub(xpriv.PrivateKey))
+		if err != nil {
+			return XPrivKey{}, err
+		}
+		data = pub
 	}
 
 	data = binary.BigEndian.AppendUint32(data, i)
@@ -149,66 +185,87 @@ type XPubKey struct {
 	Depth uint8
 	Index uint32
 
+	// ParentFingerprint is used when neither parent is known, e.g. for keys
+	// loaded with ParseExtendedKey.
+	ParentFingerprint []byte
+
 	PublicKey Point
 	ChainCode []byte
 }
 
-func (xpub XPubKey) SerializeKey(mainnet bool) string {
-	// TODO: validate that depth, parkbytes, i are consistent
+func (xpub XPubKey) SerializeKey(net Network) (string, error) {
+	return xpub.SerializeKeyVersions(net.KeyVersions)
+}
 
-	bytes := []byte{}
-	if mainnet {
-		bytes = append(bytes, []byte{0x04, 0x88, 0xB2, 0x1E}...)
-	} else {
-		bytes = append(bytes, []byte{0x04, 0x35, 0x87, 0xCF}...)
+func (xpub XPubKey) SerializeKeyVersions(versions KeyVersions) (string, error) {
+	// TODO: validate that depth, parkbytes, i are consistent
+	comp, err := Secp256k1Compressed(xpub.PublicKey)
+	if err != nil {
+		return "", err
 	}
 
-	bytes = append(bytes, byte(xpub.Depth))
-
-	isMaster := xpub.PrivParent == nil && xpub.PubParent == nil
-	if isMaster {
-		bytes = append(bytes, []byte{0x00, 0x00, 0x00, 0x00}...)
-	} else {
-		var fingerprint []byte
-		if xpub.PrivParent != nil {
-			fingerprint = xpub.PrivParent.Fingerprint()
-		} else {
-			fingerprint = xpub.PubParent.Fingerprint()
-		}
-		bytes = append(bytes, fingerprint...)
+	parentFingerprint, err := xpub.parentFingerprint()
+	if err != nil {
+		return "", err
 	}
 
+	bytes := append([]byte{}, versions.Public...)
+
+	bytes = append(bytes, byte(xpub.Depth))
+	bytes = append(bytes, parentFingerprint...)
 	bytes = binary.BigEndian.AppendUint32(bytes, xpub.Index)
 	bytes = append(bytes, xpub.ChainCode...)
 
-	comp := Secp256k1Compressed(xpub.PublicKey)
 	bytes = append(bytes, comp...)
 
-	return Base58Check(bytes)
+	return Base58Check(bytes), nil
 }
 
-func (xpub XPubKey) Identifier() []byte {
-	pubComp := Secp256k1Compressed(xpub.PublicKey)
-	hash := sha256.Sum256(pubComp)
-	ripe := ripemd160.New()
-	ripe.Write(hash[:])
+func (xpub XPubKey) parentFingerprint() ([]byte, error) {
+	if xpub.PrivParent != nil {
+		return xpub.PrivParent.Fingerprint()
+	}
+
+	if xpub.PubParent != nil {
+		return xpub.PubParent.Fingerprint()
+	}
+
+	if xpub.ParentFingerprint != nil {
+		return xpub.ParentFingerprint, nil
+	}
 
-	return ripe.Sum(nil)
+	return []byte{0x00, 0x00, 0x00, 0x00}, nil
 }
 
-func (xpub XPubKey) Fingerprint() []byte {
-	return xpub.Identifier()[:4]
+func (xpub XPubKey) Identifier() ([]byte, error) {
+	comp, err := Secp256k1Compressed(xpub.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return hash160(comp), nil
+}
+
+func (xpub XPubKey) Fingerprint() ([]byte, error) {
+	id, err := xpub.Identifier()
+	if err != nil {
+		return nil, err
+	}
+
+	return id[:4], nil
 }
 
 func (xpub XPubKey) CKDpub(i uint32) (XPubKey, error) {
-	lim := uint32(1) << 31
 	var data []byte
-	if i >= lim {
-		return XPubKey{}, fmt.Errorf("i > %d", lim)
+	if i >= HardenedKeyStart {
+		return XPubKey{}, fmt.Errorf("%w: index %d", ErrHardenedPublicDerivation, i)
 	}
 
 	// If not (normal child): let I = HMAC-SHA512(Key = cpar, Data = serP(Kpar) || ser32(i)).
-	data = Secp256k1Compressed(xpub.PublicKey)
+	data, err := Secp256k1Compressed(xpub.PublicKey)
+	if err != nil {
+		return XPubKey{}, err
+	}
 	data = binary.BigEndian.AppendUint32(data, i)
 
 	hm := hmac.New(sha512.New, xpub.ChainCode)
@@ -227,6 +284,9 @@ func (xpub XPubKey) CKDpub(i uint32) (XPubKey, error) {
 	IlPub := Secp256k1Pub(IlNum)
 
 	childPub := Secp256k1Add(xpub.PublicKey, IlPub)
+	if childPub.IsInfinity() {
+		return XPubKey{}, fmt.Errorf("resulting key is the %w", ErrPointAtInfinity)
+	}
 
 	return XPubKey{
 		PubParent: &xpub,
@@ -271,3 +331,83 @@ func MasterPrivateKey(seed *Seed) (XPrivKey, error) {
 		ChainCode:  ir,
 	}, nil
 }
+
+// ExtendedKey is implemented by XPrivKey and XPubKey.
+type ExtendedKey interface {
+	SerializeKey(net Network) (string, error)
+	SerializeKeyVersions(versions KeyVersions) (string, error)
+	Identifier() ([]byte, error)
+	Fingerprint() ([]byte, error)
+}
+
+// ParseExtendedKey decodes a Base58Check serialized extended key and returns
+// either an XPrivKey or an XPubKey, and the version set it was serialized
+// with.
+func ParseExtendedKey(s string) (ExtendedKey, KeyVersions, error) {
+	data, err := Base58CheckDecode(s)
+	if err != nil {
+		return nil, KeyVersions{}, err
+	}
+
+	if len(data) != 78 {
+		return nil, KeyVersions{}, fmt.Errorf("invalid extended key length: %d bytes", len(data))
+	}
+
+	version := data[:4]
+	depth := data[4]
+	parentFingerprint := append([]byte{}, data[5:9]...)
+	index := binary.BigEndian.Uint32(data[9:13])
+	chainCode := append([]byte{}, data[13:45]...)
+	keyData := data[45:]
+
+	versions, private, ok := lookupKeyVersions(version)
+	if !ok {
+		return nil, KeyVersions{}, fmt.Errorf("unknown extended key version: %x", version)
+	}
+
+	if depth == 0 {
+		if !bytes.Equal(parentFingerprint, []byte{0x00, 0x00, 0x00, 0x00}) {
+			return nil, KeyVersions{}, fmt.Errorf("invalid extended key: zero depth with non-zero parent fingerprint")
+		}
+
+		if index != 0 {
+			return nil, KeyVersions{}, fmt.Errorf("invalid extended key: zero depth with non-zero index")
+		}
+
+		parentFingerprint = nil
+	} else if bytes.Equal(parentFingerprint, []byte{0x00, 0x00, 0x00, 0x00}) {
+		return nil, KeyVersions{}, fmt.Errorf("invalid extended key: non-zero depth with zero parent fingerprint")
+	}
+
+	if private {
+		if keyData[0] != 0x00 {
+			return nil, KeyVersions{}, fmt.Errorf("invalid extended private key: private key data must start with 0x00")
+		}
+
+		k := big.NewInt(0).SetBytes(keyData[1:])
+		if k.Sign() == 0 || k.Cmp(secp256k1Order) >= 0 {
+			return nil, KeyVersions{}, fmt.Errorf("invalid extended private key: private key is out of range")
+		}
+
+		return XPrivKey{
+			Depth:             depth,
+			Index:             index,
+			ParentFingerprint: parentFingerprint,
+			PrivateKey:        k,
+			ChainCode:         chainCode,
+		}, versions, nil
+	}
+
+	pub, err := decompressPoint(keyData)
+	if err != nil {
+		return nil, KeyVersions{}, fmt.Errorf("invalid extended public key: %w", err)
+	}
+
+	return XPubKey{
+		Depth:             depth,
+		Index:             index,
+		ParentFingerprint: parentFingerprint,
+		PublicKey:         pub,
+		ChainCode:         chainCode,
+	}, versions, nil
+}
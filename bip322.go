@@ -0,0 +1,361 @@
+package btools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+/*
+BIP322 signs a message by spending a virtual output locked with the script of
+the address. to_spend commits to the message and pays to the address, and
+to_sign spends it to a single OP_RETURN output. The signature is the base64
+of either the witness of to_sign (simple format) or the whole signed to_sign
+transaction (full format), prefixed with "smp" or "ful". The verifier
+dispatches on the prefix. Signatures without one, as made by SignBIP322 and
+by implementations predating the prefixes, are taken as a full to_sign when
+they decode as one and as a witness otherwise. Proof of funds ("pof")
+signatures are not supported.
+
+Only single key P2WPKH and P2TR key path spends are supported.
+*/
+
+// BIP322Format is the encoding of a BIP322 signature.
+type BIP322Format int
+
+const (
+	BIP322Simple BIP322Format = iota + 1
+	BIP322Full
+)
+
+// BIP322MessageHash returns the tagged hash of msg committed to by to_spend.
+func BIP322MessageHash(msg string) []byte {
+	return TaggedHash("BIP0322-signed-message", []byte(msg))
+}
+
+// SignBIP322 signs msg for the address of type t of the private key priv,
+// which must be AddressP2WPKH or AddressP2TR. The signature is returned
+// without a format prefix.
+func SignBIP322(priv *big.Int, t AddressType, msg string, format BIP322Format) (string, error) {
+	if format != BIP322Simple && format != BIP322Full {
+		return "", fmt.Errorf("unknown BIP322 format: %d", int(format))
+	}
+
+	if priv == nil || priv.Sign() <= 0 || priv.Cmp(secp256k1Order) >= 0 {
+		return "", fmt.Errorf("invalid private key: out of range")
+	}
+
+	pub := Secp256k1Pub(priv)
+
+	var version byte
+	var program []byte
+	switch t {
+	case AddressP2WPKH:
+		program = hash160(compressPoint(pub))
+
+	case AddressP2TR:
+		output, err := TaprootOutputKey(pub)
+		if err != nil {
+			return "", err
+		}
+		version = 1
+		program = output.X.FillBytes(make([]byte, 32))
+
+	default:
+		return "", fmt.Errorf("BIP322 signatures are not supported for %s addresses", t)
+	}
+
+	script := witnessScript(version, program)
+	toSign := bip322ToSign(bip322ToSpend(script, msg))
+
+	switch t {
+	case AddressP2WPKH:
+		hash := sighashWitnessV0(toSign, 0, p2wpkhScriptCode(program), 0, sighashAll)
+		sig, err := Sign(priv, hash)
+		if err != nil {
+			return "", err
+		}
+
+		toSign.Inputs[0].Witness = TxWitness{
+			append(sig.SerializeDER(), sighashAll),
+			compressPoint(pub),
+		}
+
+	case AddressP2TR:
+		tweaked, err := taprootTweakPrivateKey(priv)
+		if err != nil {
+			return "", err
+		}
+
+		hash := sighashTaproot(toSign, 0, [][]byte{script}, []uint64{0}, sighashDefault)
+		sig, err := SchnorrSign(tweaked, hash, nil)
+		if err != nil {
+			return "", err
+		}
+
+		toSign.Inputs[0].Witness = TxWitness{sig}
+	}
+
+	var data []byte
+	if format == BIP322Simple {
+		data = toSign.Inputs[0].Witness.append(nil)
+	} else {
+		data = toSign.Serialize()
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func (xpriv XPrivKey) SignBIP322(t AddressType, msg string, format BIP322Format) (string, error) {
+	return SignBIP322(xpriv.PrivateKey, t, msg, format)
+}
+
+// VerifyBIP322 checks the simple or full BIP322 signature sig of msg for
+// address on any of the registered networks.
+func VerifyBIP322(address, msg, sig string) (bool, error) {
+	version, program, err := decodeBIP322Address(address)
+	if err != nil {
+		return false, err
+	}
+
+	var format BIP322Format
+	encoded := sig
+	if rest, ok := strings.CutPrefix(sig, "smp"); ok {
+		format, encoded = BIP322Simple, rest
+	} else if rest, ok := strings.CutPrefix(sig, "ful"); ok {
+		format, encoded = BIP322Full, rest
+	} else if strings.HasPrefix(sig, "pof") {
+		return false, fmt.Errorf("%w: proof of funds signatures are not supported", ErrInvalidSignature)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return false, fmt.Errorf("%w: invalid base64: %w", ErrInvalidSignature, err)
+	}
+
+	script := witnessScript(version, program)
+	toSpend := bip322ToSpend(script, msg)
+
+	var toSign Tx
+	if format == 0 {
+		// no prefix: a full signature is a to_sign transaction, anything
+		// else must be the witness of a simple one
+		format = BIP322Simple
+		if tx, err := parseBIP322ToSign(data); err == nil {
+			format, toSign = BIP322Full, tx
+		}
+	} else if format == BIP322Full {
+		toSign, err = parseBIP322ToSign(data)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if format == BIP322Full {
+		if toSign.Inputs[0].PrevOut.Hash != toSpend.TxID() {
+			return false, nil
+		}
+	} else {
+		witness, err := parseWitness(data)
+		if err != nil {
+			return false, err
+		}
+
+		toSign = bip322ToSign(toSpend)
+		toSign.Inputs[0].Witness = witness
+	}
+
+	witness := toSign.Inputs[0].Witness
+	if version == 0 {
+		return verifyP2WPKHWitness(toSign, program, witness), nil
+	}
+
+	return verifyP2TRWitness(toSign, script, program, witness), nil
+}
+
+// decodeBIP322Address returns the witness version and program of a P2WPKH
+// or P2TR address.
+func decodeBIP322Address(address string) (byte, []byte, error) {
+	for _, net := range Networks {
+		version, program, err := DecodeSegWitAddress(net.Bech32HRP, address)
+		if err != nil {
+			continue
+		}
+
+		if version == 0 && len(program) == 20 || version == 1 && len(program) == 32 {
+			return version, program, nil
+		}
+
+		return 0, nil, fmt.Errorf("BIP322 signatures are only supported for p2wpkh and p2tr addresses")
+	}
+
+	return 0, nil, fmt.Errorf("invalid address %q: not a SegWit address of a registered network", address)
+}
+
+func verifyP2WPKHWitness(tx Tx, program []byte, witness TxWitness) bool {
+	if len(witness) != 2 || len(witness[0]) == 0 || len(witness[1]) != 33 {
+		return false
+	}
+
+	sigData := witness[0]
+	if sigData[len(sigData)-1] != sighashAll {
+		return false
+	}
+
+	sig, err := ParseDERSignature(sigData[:len(sigData)-1])
+	if err != nil || !sig.IsLowS() {
+		return false
+	}
+
+	pub, err := ParsePublicKey(witness[1])
+	if err != nil || !bytes.Equal(hash160(witness[1]), program) {
+		return false
+	}
+
+	hash := sighashWitnessV0(tx, 0, p2wpkhScriptCode(program), 0, sighashAll)
+
+	return Verify(pub, hash, sig)
+}
+
+func verifyP2TRWitness(tx Tx, script, program []byte, witness TxWitness) bool {
+	if len(witness) != 1 {
+		return false
+	}
+
+	sig := witness[0]
+	hashType := byte(sighashDefault)
+	switch {
+	case len(sig) == 65 && sig[64] == sighashAll:
+		hashType = sighashAll
+		sig = sig[:64]
+	case len(sig) != 64:
+		return false
+	}
+
+	pub, err := ParseXOnlyPubKey(program)
+	if err != nil {
+		return false
+	}
+
+	hash := sighashTaproot(tx, 0, [][]byte{script}, []uint64{0}, hashType)
+
+	return SchnorrVerify(pub, hash, sig)
+}
+
+// taprootTweakPrivateKey returns the private key of the output key computed
+// by TaprootOutputKey.
+func taprootTweakPrivateKey(priv *big.Int) (*big.Int, error) {
+	pub := Secp256k1Pub(priv)
+
+	d := big.NewInt(0).Set(priv)
+	if pub.Y.Bit(0) == 1 {
+		d.Sub(secp256k1Order, d)
+	}
+
+	tweak := big.NewInt(0).SetBytes(TaggedHash("TapTweak", pub.X.FillBytes(make([]byte, 32))))
+	if tweak.Cmp(secp256k1Order) >= 0 {
+		return nil, fmt.Errorf("invalid taproot tweak")
+	}
+
+	d.Add(d, tweak).Mod(d, secp256k1Order)
+	if d.Sign() == 0 {
+		return nil, fmt.Errorf("invalid taproot tweaked key")
+	}
+
+	return d, nil
+}
+
+// witnessScript returns the scriptPubKey paying to a witness program.
+func witnessScript(version byte, program []byte) []byte {
+	op := version
+	if version > 0 {
+		// OP_1 to OP_16
+		op = 0x50 + version
+	}
+
+	return append([]byte{op, byte(len(program))}, program...)
+}
+
+// p2wpkhScriptCode is the script code of BIP143 for a P2WPKH output:
+// OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG.
+func p2wpkhScriptCode(keyHash []byte) []byte {
+	script := append([]byte{0x76, 0xA9, 0x14}, keyHash...)
+	return append(script, 0x88, 0xAC)
+}
+
+func bip322ToSpend(script []byte, msg string) Tx {
+	return Tx{
+		Inputs: []TxIn{{
+			PrevOut: OutPoint{Index: 0xFFFFFFFF},
+			// OP_0 PUSH32[message_hash]
+			ScriptSig: append([]byte{0x00, 0x20}, BIP322MessageHash(msg)...),
+		}},
+		Outputs: []TxOut{{ScriptPubKey: script}},
+	}
+}
+
+func bip322ToSign(toSpend Tx) Tx {
+	return Tx{
+		Inputs: []TxIn{{PrevOut: OutPoint{Hash: toSpend.TxID()}}},
+		// OP_RETURN
+		Outputs: []TxOut{{ScriptPubKey: []byte{0x6A}}},
+	}
+}
+
+// checkBIP322ToSign checks the structure of the to_sign transaction of a
+// full signature. Whether it spends to_spend depends on the message and is
+// checked by the caller.
+// parseBIP322ToSign parses the to_sign transaction of a full signature.
+func parseBIP322ToSign(data []byte) (Tx, error) {
+	toSign, err := ParseTx(data)
+	if err != nil {
+		return Tx{}, fmt.Errorf("%w: invalid full signature: %w", ErrInvalidSignature, err)
+	}
+
+	if err := checkBIP322ToSign(toSign); err != nil {
+		return Tx{}, err
+	}
+
+	return toSign, nil
+}
+
+func checkBIP322ToSign(toSign Tx) error {
+	if toSign.Version != 0 && toSign.Version != 2 {
+		return fmt.Errorf("%w: unsupported to_sign version %d", ErrInvalidSignature, toSign.Version)
+	}
+
+	if len(toSign.Inputs) != 1 {
+		return fmt.Errorf("%w: to_sign must have a single input", ErrInvalidSignature)
+	}
+
+	in := toSign.Inputs[0]
+	if in.PrevOut.Index != 0 {
+		return fmt.Errorf("%w: to_sign must spend the output 0 of to_spend", ErrInvalidSignature)
+	}
+
+	if len(in.ScriptSig) != 0 {
+		return fmt.Errorf("%w: to_sign has a scriptSig", ErrInvalidSignature)
+	}
+
+	if len(toSign.Outputs) != 1 || toSign.Outputs[0].Value != 0 || !bytes.Equal(toSign.Outputs[0].ScriptPubKey, []byte{0x6A}) {
+		return fmt.Errorf("%w: to_sign must have a single empty OP_RETURN output", ErrInvalidSignature)
+	}
+
+	return nil
+}
+
+func parseWitness(data []byte) (TxWitness, error) {
+	r := &txReader{data: data}
+	witness := r.witness()
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: invalid witness: %w", ErrInvalidSignature, r.err)
+	}
+
+	if len(r.data) != 0 {
+		return nil, fmt.Errorf("%w: trailing data after witness", ErrInvalidSignature)
+	}
+
+	return witness, nil
+}
@@ -0,0 +1,201 @@
+package btools
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"slices"
+	"testing"
+)
+
+type bip322Vectors struct {
+	TxHashes []struct {
+		Message       string `json:"message"`
+		Address       string `json:"address"`
+		MessageHash   string `json:"message_hash"`
+		ToSpendTxHash string `json:"to_spend_tx_hash"`
+		ToSignTxHash  string `json:"to_sign_tx_hash"`
+	} `json:"tx_hashes"`
+	Simple []bip322Vector `json:"simple"`
+	Full   []bip322Vector `json:"full"`
+	Error  []struct {
+		Description string `json:"description"`
+		Message     string `json:"message"`
+		Address     string `json:"address"`
+		Signature   string `json:"signature"`
+	} `json:"error"`
+}
+
+type bip322Vector struct {
+	Message     string   `json:"message"`
+	PrivateKeys []string `json:"private_keys"`
+	Address     string   `json:"address"`
+	Type        string   `json:"type"`
+	Signatures  []string `json:"bip322_signatures"`
+}
+
+// The vectors of testdata/bip322_*_vectors.json are basic-test-vectors.json
+// and generated-test-vectors.json of https://github.com/bitcoin/bips
+// (bip-0322).
+func readBIP322Vectors(t *testing.T, file string) bip322Vectors {
+	data, err := os.ReadFile("testdata/" + file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors bip322Vectors
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("%s: %v", file, err)
+	}
+
+	return vectors
+}
+
+func TestBIP322TxHashes(t *testing.T) {
+	vectors := readBIP322Vectors(t, "bip322_basic_vectors.json")
+
+	for _, v := range vectors.TxHashes {
+		if got := hex.EncodeToString(BIP322MessageHash(v.Message)); got != v.MessageHash {
+			t.Errorf("BIP322MessageHash(%q) = %s, want %s", v.Message, got, v.MessageHash)
+		}
+
+		version, program, err := decodeBIP322Address(v.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		toSpend := bip322ToSpend(witnessScript(version, program), v.Message)
+		if got := toSpend.TxID().String(); got != v.ToSpendTxHash {
+			t.Errorf("to_spend of %q: %s, want %s", v.Message, got, v.ToSpendTxHash)
+		}
+
+		if got := bip322ToSign(toSpend).TxID().String(); got != v.ToSignTxHash {
+			t.Errorf("to_sign of %q: %s, want %s", v.Message, got, v.ToSignTxHash)
+		}
+	}
+}
+
+func TestBIP322Vectors(t *testing.T) {
+	for _, file := range []string{"bip322_basic_vectors.json", "bip322_generated_vectors.json"} {
+		vectors := readBIP322Vectors(t, file)
+
+		formats := []struct {
+			format  BIP322Format
+			vectors []bip322Vector
+		}{
+			{BIP322Simple, vectors.Simple},
+			{BIP322Full, vectors.Full},
+		}
+
+		for _, f := range formats {
+			for _, v := range f.vectors {
+				var addrType AddressType
+				switch v.Type {
+				case "p2wpkh":
+					addrType = AddressP2WPKH
+				case "p2tr":
+					addrType = AddressP2TR
+				default:
+					// only single key P2WPKH and P2TR are supported
+					if ok, _ := VerifyBIP322(v.Address, v.Message, v.Signatures[0]); ok {
+						t.Errorf("%s: verified an unsupported %s signature", file, v.Type)
+					}
+					continue
+				}
+
+				for _, sig := range v.Signatures {
+					if ok, err := VerifyBIP322(v.Address, v.Message, sig); !ok || err != nil {
+						t.Errorf("%s: %s signature of %q for %s: %v, %v", file, v.Type, v.Message, v.Address, ok, err)
+					}
+				}
+
+				wif, err := DecodeWIF(v.PrivateKeys[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				sig, err := SignBIP322(wif.PrivateKey, addrType, v.Message, f.format)
+				if err != nil {
+					t.Fatalf("%s: SignBIP322(%q): %v", file, v.Message, err)
+				}
+
+				if _, err := base64.StdEncoding.DecodeString(sig); err != nil {
+					t.Errorf("%s: SignBIP322(%q) = %s is not base64", file, v.Message, sig)
+				}
+
+				if ok, err := VerifyBIP322(v.Address, v.Message, sig); !ok || err != nil {
+					t.Errorf("%s: own %s signature of %q: %v, %v", file, v.Type, v.Message, ok, err)
+				}
+
+				if ok, _ := VerifyBIP322(v.Address, v.Message+".", sig); ok {
+					t.Errorf("%s: %s signature of %q verified for another message", file, v.Type, v.Message)
+				}
+
+				// RFC 6979 makes P2WPKH signatures deterministic, while the
+				// full vectors use another version, lock time and sequence
+				if addrType == AddressP2WPKH && f.format == BIP322Simple && !slices.Contains(v.Signatures, "smp"+sig) {
+					t.Errorf("%s: SignBIP322(%q) = %s, want one of %v without its prefix", file, v.Message, sig, v.Signatures)
+				}
+			}
+		}
+
+		for _, v := range vectors.Error {
+			if ok, _ := VerifyBIP322(v.Address, v.Message, v.Signature); ok {
+				t.Errorf("%s: %s: verified", file, v.Description)
+			}
+		}
+	}
+}
+
+func TestBIP322Formats(t *testing.T) {
+	priv := hexInt("c0ffee254729296a45a3885639ac7e10f9d54979c0ffee254729296a45a38856")
+
+	for _, addrType := range []AddressType{AddressP2WPKH, AddressP2TR} {
+		address, err := Address(Secp256k1Pub(priv), addrType, Mainnet)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, format := range []BIP322Format{BIP322Simple, BIP322Full} {
+			sig, err := SignBIP322(priv, addrType, "Hello World", format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, _ := base64.StdEncoding.DecodeString(sig)
+			if _, err := ParseTx(data); (err == nil) != (format == BIP322Full) {
+				t.Errorf("%s signature of format %d: ParseTx returned %v", addrType, format, err)
+			}
+
+			if ok, err := VerifyBIP322(address, "Hello World", sig); !ok || err != nil {
+				t.Errorf("%s signature of format %d: %v, %v", addrType, format, ok, err)
+			}
+
+			// the prefix of the format verifies, the other one and proof
+			// of funds do not
+			prefix, wrong := "smp", "ful"
+			if format == BIP322Full {
+				prefix, wrong = wrong, prefix
+			}
+
+			if ok, err := VerifyBIP322(address, "Hello World", prefix+sig); !ok || err != nil {
+				t.Errorf("%s signature of format %d with prefix %s: %v, %v", addrType, format, prefix, ok, err)
+			}
+
+			for _, p := range []string{wrong, "pof"} {
+				if ok, _ := VerifyBIP322(address, "Hello World", p+sig); ok {
+					t.Errorf("%s signature of format %d verified with prefix %s", addrType, format, p)
+				}
+			}
+		}
+	}
+
+	if _, err := SignBIP322(priv, AddressP2WPKH, "", BIP322Format(3)); err == nil {
+		t.Errorf("SignBIP322 accepted an unknown format")
+	}
+
+	if _, err := SignBIP322(priv, AddressP2PKH, "", BIP322Simple); err == nil {
+		t.Errorf("SignBIP322 accepted a p2pkh address")
+	}
+}
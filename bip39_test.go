@@ -0,0 +1,271 @@
+package btools
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// testdata/bip39_vectors.json holds the English vectors of
+// https://github.com/trezor/python-mnemonic (vectors.json): entropy,
+// mnemonic, seed and master xprv, all with the passphrase TREZOR.
+func readBIP39Vectors(t *testing.T) [][4]string {
+	data, err := os.ReadFile("testdata/bip39_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors struct {
+		English [][4]string `json:"english"`
+	}
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	return vectors.English
+}
+
+func TestBIP39Vectors(t *testing.T) {
+	for _, v := range readBIP39Vectors(t) {
+		entropy, _ := hex.DecodeString(v[0])
+		mnemonic := strings.Fields(v[1])
+
+		words, err := Mnemonic(entropy)
+		if err != nil || strings.Join(words, " ") != v[1] {
+			t.Errorf("Mnemonic(%s) = %s, %v, want %s", v[0], strings.Join(words, " "), err, v[1])
+		}
+
+		got, err := MnemonicToEntropy(mnemonic)
+		if err != nil || hex.EncodeToString(got) != v[0] {
+			t.Errorf("MnemonicToEntropy(%s) = %x, %v, want %s", v[1], got, err, v[0])
+		}
+
+		seed, err := NewSeed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("NewSeed(%s): %v", v[1], err)
+		}
+
+		if got := hex.EncodeToString(seed.Seed); got != v[2] {
+			t.Errorf("NewSeed(%s) = %s, want %s", v[1], got, v[2])
+		}
+
+		master, err := MasterPrivateKey(seed)
+		if err != nil {
+			t.Fatalf("MasterPrivateKey(%s): %v", v[1], err)
+		}
+
+		if got, err := master.SerializeKey(Mainnet); err != nil || got != v[3] {
+			t.Errorf("MasterPrivateKey(%s) = %s, %v, want %s", v[1], got, err, v[3])
+		}
+	}
+}
+
+func TestMnemonicToEntropyErrors(t *testing.T) {
+	valid := strings.Fields("legal winner thank year wave sausage worth useful legal winner thank yellow")
+
+	// the last word carries the checksum, any other word breaks it
+	badChecksum := append(append([]string{}, valid[:11]...), "abandon")
+	if _, err := MnemonicToEntropy(badChecksum); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("MnemonicToEntropy(%v) returned %v, want ErrChecksumMismatch", badChecksum, err)
+	}
+
+	swapped := append([]string{}, valid...)
+	swapped[0], swapped[1] = swapped[1], swapped[0]
+	if _, err := MnemonicToEntropy(swapped); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("MnemonicToEntropy(%v) returned %v, want ErrChecksumMismatch", swapped, err)
+	}
+
+	for _, l := range []int{0, 11, 13, 25} {
+		mnemonic := make([]string, l)
+		for i := range mnemonic {
+			mnemonic[i] = "abandon"
+		}
+
+		if _, err := MnemonicToEntropy(mnemonic); !errors.Is(err, ErrInvalidWordCount) {
+			t.Errorf("MnemonicToEntropy of %d words returned %v, want ErrInvalidWordCount", l, err)
+		}
+	}
+
+	unknown := append([]string{}, valid...)
+	unknown[4] = "waves"
+	var wordErr UnknownWordError
+	if _, err := MnemonicToEntropy(unknown); !errors.As(err, &wordErr) || wordErr.Position != 4 || wordErr.Word != "waves" {
+		t.Errorf("MnemonicToEntropy(%v) returned %v, want an unknown word at 4", unknown, err)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	entropy, _ := hex.DecodeString("7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f")
+	for _, lang := range Languages {
+		mnemonic, err := MnemonicIn(lang, entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got, err := DetectLanguage(mnemonic); err != nil || got != lang {
+			t.Errorf("DetectLanguage(%s mnemonic) = %s, %v", lang, got, err)
+		}
+	}
+
+	// these words are in both the English and the French wordlists
+	tests := []struct {
+		mnemonic string
+		want     Language
+	}{
+		// the checksum is only valid in French
+		{"abandon amateur angle animal aspect badge bicycle bonus brave canal capable festival", French},
+		// valid in both, the first language wins
+		{"amateur angle animal aspect badge bicycle bonus brave canal capable caution wagon", English},
+		// valid in neither, the first language wins
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", English},
+	}
+
+	for _, tt := range tests {
+		if got, err := DetectLanguage(strings.Fields(tt.mnemonic)); err != nil || got != tt.want {
+			t.Errorf("DetectLanguage(%s) = %s, %v, want %s", tt.mnemonic, got, err, tt.want)
+		}
+	}
+
+	// the closest wordlist reports the unknown word
+	var wordErr UnknownWordError
+	mnemonic := strings.Fields("legal winner thank year wave sausage worth useful legal winner thank yelow")
+	if _, err := DetectLanguage(mnemonic); !errors.As(err, &wordErr) || wordErr.Position != 11 {
+		t.Errorf("DetectLanguage(%v) returned %v, want an unknown word at 11", mnemonic, err)
+	}
+}
+
+func TestFinalWords(t *testing.T) {
+	for _, v := range readBIP39Vectors(t) {
+		mnemonic := strings.Fields(v[1])
+		partial := mnemonic[:len(mnemonic)-1]
+
+		words, err := FinalWords(partial)
+		if err != nil {
+			t.Fatalf("FinalWords(%v): %v", partial, err)
+		}
+
+		// 2^(11-checksum bits) candidates: 128 for 12 words, 8 for 24
+		if want := 1 << (11 - len(mnemonic)/3); len(words) != want {
+			t.Errorf("FinalWords(%v) returned %d words, want %d", partial, len(words), want)
+		}
+
+		if !slices.Contains(words, mnemonic[len(mnemonic)-1]) {
+			t.Errorf("FinalWords(%v) does not contain %s", partial, mnemonic[len(mnemonic)-1])
+		}
+
+		for _, w := range words {
+			if _, err := MnemonicToEntropy(append(slices.Clone(partial), w)); err != nil {
+				t.Errorf("FinalWords(%v): %s gives %v", partial, w, err)
+			}
+		}
+	}
+
+	words, err := FinalWordsIn(Japanese, strings.Split(readJapaneseVectors(t)[0].Mnemonic, "　")[:11])
+	if err != nil || len(words) != 128 {
+		t.Errorf("FinalWordsIn(Japanese) = %d words, %v", len(words), err)
+	}
+
+	if _, err := FinalWords(make([]string, 12)); !errors.Is(err, ErrInvalidWordCount) {
+		t.Errorf("FinalWords of 12 words returned %v, want ErrInvalidWordCount", err)
+	}
+
+	var wordErr UnknownWordError
+	if _, err := FinalWords(strings.Fields("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandonn")); !errors.As(err, &wordErr) || wordErr.Position != 10 {
+		t.Errorf("FinalWords returned %v, want an unknown word at 10", err)
+	}
+}
+
+// testdata/bip39_japanese_vectors.json has the layout of test_JP_BIP39.json
+// of https://github.com/bip32JP/bip32JP.github.io: the entropies of the
+// English vectors as Japanese mnemonics joined with U+3000, with a passphrase
+// whose seed only matches once it is NFKD normalized.
+type japaneseVector struct {
+	Entropy    string `json:"entropy"`
+	Mnemonic   string `json:"mnemonic"`
+	Passphrase string `json:"passphrase"`
+	Seed       string `json:"seed"`
+	XPrv       string `json:"bip32_xprv"`
+}
+
+func readJapaneseVectors(t *testing.T) []japaneseVector {
+	data, err := os.ReadFile("testdata/bip39_japanese_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []japaneseVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	return vectors
+}
+
+func TestJapaneseVectors(t *testing.T) {
+	wl, err := GetWordlist(Japanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range readJapaneseVectors(t) {
+		entropy, _ := hex.DecodeString(v.Entropy)
+
+		words, err := MnemonicIn(Japanese, entropy)
+		if err != nil {
+			t.Fatalf("MnemonicIn(%s): %v", v.Entropy, err)
+		}
+
+		if got := norm.NFC.String(wl.Join(words)); got != v.Mnemonic {
+			t.Errorf("MnemonicIn(%s) = %q, want %q", v.Entropy, got, v.Mnemonic)
+		}
+
+		mnemonic := strings.Split(v.Mnemonic, "　")
+		lang, err := DetectLanguage(mnemonic)
+		if err != nil || lang != Japanese {
+			t.Errorf("DetectLanguage(%q) = %s, %v, want %s", v.Mnemonic, lang, err, Japanese)
+		}
+
+		got, err := MnemonicToEntropyIn(Japanese, mnemonic)
+		if err != nil || hex.EncodeToString(got) != v.Entropy {
+			t.Errorf("MnemonicToEntropyIn(%q) = %x, %v, want %s", v.Mnemonic, got, err, v.Entropy)
+		}
+
+		// the vectors are NFC, the seed must not depend on the form of
+		// either the mnemonic or the passphrase
+		forms := []struct {
+			name       string
+			mnemonic   []string
+			passphrase string
+		}{
+			{"NFC", mnemonic, v.Passphrase},
+			{"NFD", strings.Fields(norm.NFD.String(v.Mnemonic)), norm.NFD.String(v.Passphrase)},
+			{"NFKD", strings.Fields(norm.NFKD.String(v.Mnemonic)), norm.NFKD.String(v.Passphrase)},
+		}
+
+		for _, f := range forms {
+			seed, err := NewSeed(f.mnemonic, f.passphrase)
+			if err != nil {
+				t.Fatalf("NewSeed(%s): %v", f.name, err)
+			}
+
+			if got := hex.EncodeToString(seed.Seed); got != v.Seed {
+				t.Errorf("NewSeed(%s) = %s, want %s", f.name, got, v.Seed)
+			}
+
+			master, err := MasterPrivateKey(seed)
+			if err != nil {
+				t.Fatalf("MasterPrivateKey(%s): %v", f.name, err)
+			}
+
+			if got, err := master.SerializeKey(Mainnet); err != nil || got != v.XPrv {
+				t.Errorf("MasterPrivateKey(%s) = %s, %v, want %s", f.name, got, err, v.XPrv)
+			}
+		}
+	}
+}
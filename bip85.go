@@ -0,0 +1,184 @@
+package btools
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const bip85Purpose = 83696968
+
+const (
+	BIP85AppBIP39       = 39
+	BIP85AppHDSeedWIF   = 2
+	BIP85AppXPRV        = 32
+	BIP85AppHex         = 128169
+	BIP85AppPasswordB64 = 707764
+	BIP85AppPasswordB85 = 707785
+)
+
+var ErrNoBIP85LanguageCode = errors.New("language has no BIP85 code")
+
+// bip85LanguageCodes are the language codes of BIP85, which defines none for
+// Portuguese.
+var bip85LanguageCodes = map[Language]uint32{
+	English:            0,
+	Japanese:           1,
+	Korean:             2,
+	Spanish:            3,
+	ChineseSimplified:  4,
+	ChineseTraditional: 5,
+	French:             6,
+	Italian:            7,
+	Czech:              8,
+}
+
+// BIP85Entropy derives the key at m/83696968'/path from root, where every
+// index of path is hardened, and returns the 64 bytes of entropy extracted
+// from it.
+func BIP85Entropy(root XPrivKey, path ...uint32) ([]byte, error) {
+	hardenedPath := DerivationPath{bip85Purpose + HardenedKeyStart}
+	for _, i := range path {
+		if i >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid BIP85 path index: %d", i)
+		}
+
+		hardenedPath = append(hardenedPath, i+HardenedKeyStart)
+	}
+
+	key, err := root.DerivePath(hardenedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha512.New, []byte("bip-entropy-from-k"))
+	mac.Write(key.PrivateKey.FillBytes(make([]byte, 32)))
+
+	return mac.Sum(nil), nil
+}
+
+func BIP85Mnemonic(root XPrivKey, lang Language, words int, index uint32) ([]string, error) {
+	if _, err := GetWordlist(lang); err != nil {
+		return nil, err
+	}
+
+	code, ok := bip85LanguageCodes[lang]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoBIP85LanguageCode, lang)
+	}
+
+	if words != 12 && words != 15 && words != 18 && words != 21 && words != 24 {
+		return nil, fmt.Errorf("%w: %d words", ErrInvalidWordCount, words)
+	}
+
+	entropy, err := BIP85Entropy(root, BIP85AppBIP39, code, uint32(words), index)
+	if err != nil {
+		return nil, err
+	}
+
+	// 11 bits per word, one of every 33 bits is checksum
+	return MnemonicIn(lang, entropy[:words*4/3])
+}
+
+// BIP85HDSeedWIF returns a compressed mainnet WIF private key to be used as
+// the hdseed of a Bitcoin Core wallet.
+func BIP85HDSeedWIF(root XPrivKey, index uint32) (string, error) {
+	entropy, err := BIP85Entropy(root, BIP85AppHDSeedWIF, index)
+	if err != nil {
+		return "", err
+	}
+
+	if err := bip85CheckPrivateKey(entropy[:32]); err != nil {
+		return "", err
+	}
+
+	return EncodeWIF(big.NewInt(0).SetBytes(entropy[:32]), true, Mainnet)
+}
+
+func BIP85XPrv(root XPrivKey, index uint32) (XPrivKey, error) {
+	entropy, err := BIP85Entropy(root, BIP85AppXPRV, index)
+	if err != nil {
+		return XPrivKey{}, err
+	}
+
+	// the chain code comes first, reversing the order used by BIP32
+	if err := bip85CheckPrivateKey(entropy[32:]); err != nil {
+		return XPrivKey{}, err
+	}
+
+	return XPrivKey{
+		PrivateKey: big.NewInt(0).SetBytes(entropy[32:]),
+		ChainCode:  entropy[:32],
+	}, nil
+}
+
+func BIP85Hex(root XPrivKey, numBytes int, index uint32) ([]byte, error) {
+	if numBytes < 16 || numBytes > 64 {
+		return nil, fmt.Errorf("invalid number of bytes: %d", numBytes)
+	}
+
+	entropy, err := BIP85Entropy(root, BIP85AppHex, uint32(numBytes), index)
+	if err != nil {
+		return nil, err
+	}
+
+	return entropy[:numBytes], nil
+}
+
+func BIP85PasswordBase64(root XPrivKey, length int, index uint32) (string, error) {
+	if length < 20 || length > 86 {
+		return "", fmt.Errorf("invalid password length: %d", length)
+	}
+
+	entropy, err := BIP85Entropy(root, BIP85AppPasswordB64, uint32(length), index)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(entropy)[:length], nil
+}
+
+func BIP85PasswordBase85(root XPrivKey, length int, index uint32) (string, error) {
+	if length < 10 || length > 80 {
+		return "", fmt.Errorf("invalid password length: %d", length)
+	}
+
+	entropy, err := BIP85Entropy(root, BIP85AppPasswordB85, uint32(length), index)
+	if err != nil {
+		return "", err
+	}
+
+	return base85(entropy)[:length], nil
+}
+
+func bip85CheckPrivateKey(k []byte) error {
+	n := big.NewInt(0).SetBytes(k)
+	if n.Sign() == 0 || n.Cmp(secp256k1Order) >= 0 {
+		return fmt.Errorf("derived private key is out of range, use the next index")
+	}
+
+	return nil
+}
+
+// base85 encodes data with the RFC 1924 alphabet. len(data) must be a
+// multiple of 4.
+func base85(data []byte) string {
+	symbols := "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz!#$%&()*+-;<=>?@^_`{|}~"
+
+	result := make([]byte, 0, len(data)/4*5)
+	for i := 0; i < len(data); i += 4 {
+		v := uint32(data[i])<<24 | uint32(data[i+1])<<16 | uint32(data[i+2])<<8 | uint32(data[i+3])
+
+		chunk := make([]byte, 5)
+		for j := 4; j >= 0; j-- {
+			chunk[j] = symbols[v%85]
+			v /= 85
+		}
+		result = append(result, chunk...)
+	}
+
+	return string(result)
+}
@@ -0,0 +1,122 @@
+package btools
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// test vectors of BIP85, all derived from this master key
+const bip85RootKey = "xprv9s21ZrQH143K2LBWUUQRFXhucrQqBpKdRRxNVq2zBqsx8HVqFk2uYo8kmbaLLHRdqtQpUm98uKfu3vca1LqdGhUtyoFnCNkfmXRyPXLjbKb"
+
+func bip85Root(t *testing.T) XPrivKey {
+	key, _, err := ParseExtendedKey(bip85RootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, ok := key.(XPrivKey)
+	if !ok {
+		t.Fatalf("%s is not a private key", bip85RootKey)
+	}
+
+	return root
+}
+
+func TestBIP85Entropy(t *testing.T) {
+	root := bip85Root(t)
+
+	tests := []struct {
+		path []uint32
+		want string
+	}{
+		{[]uint32{0, 0}, "efecfbccffea313214232d29e71563d941229afb4338c21f9517c41aaa0d16f00b83d2a09ef747e7a64e8e2bd5a14869e693da66ce94ac2da570ab7ee48618f7"},
+		{[]uint32{0, 1}, "70c6e3e8ebee8dc4c0dbba66076819bb8c09672527c4277ca8729532ad711872218f826919f6b67218adde99018a6df9095ab2b58d803b5b93ec9802085a690e"},
+	}
+
+	for _, tt := range tests {
+		got, err := BIP85Entropy(root, tt.path...)
+		if err != nil {
+			t.Errorf("BIP85Entropy(%v): %v", tt.path, err)
+			continue
+		}
+
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("BIP85Entropy(%v) = %x, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	if _, err := BIP85Entropy(root, HardenedKeyStart); err == nil {
+		t.Errorf("BIP85Entropy accepted a hardened index")
+	}
+}
+
+func TestBIP85Mnemonic(t *testing.T) {
+	root := bip85Root(t)
+
+	tests := []struct {
+		words int
+		want  string
+	}{
+		{12, "girl mad pet galaxy egg matter matrix prison refuse sense ordinary nose"},
+		{18, "near account window bike charge season chef number sketch tomorrow excuse sniff circle vital hockey outdoor supply token"},
+		{24, "puppy ocean match cereal symbol another shed magic wrap hammer bulb intact gadget divorce twin tonight reason outdoor destroy simple truth cigar social volcano"},
+	}
+
+	for _, tt := range tests {
+		got, err := BIP85Mnemonic(root, English, tt.words, 0)
+		if err != nil {
+			t.Errorf("BIP85Mnemonic(%d): %v", tt.words, err)
+			continue
+		}
+
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("BIP85Mnemonic(%d) = %s, want %s", tt.words, strings.Join(got, " "), tt.want)
+		}
+	}
+
+	if _, err := BIP85Mnemonic(root, English, 13, 0); err == nil {
+		t.Errorf("BIP85Mnemonic accepted 13 words")
+	}
+
+	// BIP85 defines no language code for Portuguese
+	if _, err := BIP85Mnemonic(root, Portuguese, 12, 0); !errors.Is(err, ErrNoBIP85LanguageCode) {
+		t.Errorf("BIP85Mnemonic(Portuguese) returned %v, want ErrNoBIP85LanguageCode", err)
+	}
+
+	if _, err := BIP85Mnemonic(root, Language("klingon"), 12, 0); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("BIP85Mnemonic(klingon) returned %v, want ErrUnknownLanguage", err)
+	}
+}
+
+func TestBIP85Applications(t *testing.T) {
+	root := bip85Root(t)
+
+	wif, err := BIP85HDSeedWIF(root, 0)
+	if err != nil || wif != "Kzyv4uF39d4Jrw2W7UryTHwZr1zQVNk4dAFyqE6BuMrMh1Za7uhp" {
+		t.Errorf("BIP85HDSeedWIF = %s, %v", wif, err)
+	}
+
+	xprv, err := BIP85XPrv(root, 0)
+	if err != nil {
+		t.Errorf("BIP85XPrv: %v", err)
+	} else if got, err := xprv.SerializeKey(Mainnet); err != nil || got != "xprv9s21ZrQH143K2srSbCSg4m4kLvPMzcWydgmKEnMmoZUurYuBuYG46c6P71UGXMzmriLzCCBvKQWBUv3vPB3m1SATMhp3uEjXHJ42jFg7myX" {
+		t.Errorf("BIP85XPrv = %s, %v", got, err)
+	}
+
+	h, err := BIP85Hex(root, 64, 0)
+	if err != nil || hex.EncodeToString(h) != "492db4698cf3b73a5a24998aa3e9d7fa96275d85724a91e71aa2d645442f878555d078fd1f1f67e368976f04137b1f7a0d19232136ca50c44614af72b5582a5c" {
+		t.Errorf("BIP85Hex = %x, %v", h, err)
+	}
+
+	pwd, err := BIP85PasswordBase64(root, 21, 0)
+	if err != nil || pwd != "dKLoepugzdVJvdL56ogNV" {
+		t.Errorf("BIP85PasswordBase64 = %s, %v", pwd, err)
+	}
+
+	pwd, err = BIP85PasswordBase85(root, 12, 0)
+	if err != nil || pwd != "_s`{TW89)i4`" {
+		t.Errorf("BIP85PasswordBase85 = %s, %v", pwd, err)
+	}
+}
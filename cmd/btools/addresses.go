@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/artilugio0/btools"
+)
+
+type addressEntry struct {
+	Index     uint32 `json:"index"`
+	Path      string `json:"path"`
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+}
+
+func runAddresses(args []string) error {
+	fs := flag.NewFlagSet("addresses", flag.ExitOnError)
+	purpose := fs.Uint("purpose", 84, "derivation purpose: 44, 49, 84 or 86")
+	account := fs.Uint("account", 0, "account index, must match the key when reading an account key")
+	start := fs.Uint("start", 0, "first address index")
+	count := fs.Uint("count", 20, "number of addresses per chain")
+	networkFlag := fs.String("network", "", "network of the addresses (default mainnet, or the network of an extended key)")
+	format := fs.String("format", "table", "output format: table, csv or json")
+	passphrase := fs.String("passphrase", "", "passphrase of the mnemonic")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools addresses [flags]")
+		fmt.Fprintln(fs.Output(), "lists the receive and change addresses of an account; reads a mnemonic, a master or account xprv, or an account xpub from stdin")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *count == 0 {
+		fs.Usage()
+		return fmt.Errorf("-count must be positive")
+	}
+
+	// non-hardened indexes only, start+count is not computed as it may overflow
+	if *start >= uint(btools.HardenedKeyStart) || *count > uint(btools.HardenedKeyStart)-*start {
+		fs.Usage()
+		return fmt.Errorf("-start plus -count must not exceed %d", btools.HardenedKeyStart)
+	}
+
+	addrType, err := btools.AddressTypeForPurpose(uint32(*purpose))
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	input := []string{}
+	for scanner.Scan() {
+		input = append(input, strings.Fields(scanner.Text())...)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	network := btools.Mainnet
+	if *networkFlag != "" {
+		network, err = btools.GetNetwork(*networkFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	flagSet := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { flagSet[f.Name] = true })
+
+	var accountKey btools.XPubKey
+	var accountPath btools.DerivationPath
+	if len(input) == 1 {
+		key, versions, err := btools.ParseExtendedKey(input[0])
+		if err != nil {
+			return err
+		}
+
+		if *networkFlag == "" {
+			network, err = btools.NetworkForKeyVersions(versions)
+			if err != nil {
+				return fmt.Errorf("%w (use -network)", err)
+			}
+		}
+
+		addressesCheckKeyVersions(versions, uint32(*purpose), network)
+
+		accountKey, accountPath, err = addressesAccountKey(key, uint32(*purpose), network, uint32(*account))
+		if err != nil {
+			return err
+		}
+	} else {
+		seed, err := btools.NewSeed(input, *passphrase)
+		if err != nil {
+			return err
+		}
+
+		master, err := btools.MasterPrivateKey(seed)
+		if err != nil {
+			return err
+		}
+
+		accountKey, accountPath, err = addressesAccountKey(master, uint32(*purpose), network, uint32(*account))
+		if err != nil {
+			return err
+		}
+	}
+
+	if flagSet["account"] && accountPath[2] != uint32(*account)+btools.HardenedKeyStart {
+		return fmt.Errorf("-account %d conflicts with the account key, which is at %s", *account, accountPath)
+	}
+
+	entries := []addressEntry{}
+	for _, chain := range []uint32{0, 1} {
+		chainKey, err := accountKey.CKDpub(chain)
+		if err != nil {
+			return err
+		}
+
+		for i := uint32(*start); i < uint32(*start)+uint32(*count); i++ {
+			key, err := chainKey.CKDpub(i)
+			if err != nil {
+				return err
+			}
+
+			address, err := key.Address(addrType, network)
+			if err != nil {
+				return err
+			}
+
+			pub, err := btools.Secp256k1Compressed(key.PublicKey)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, addressEntry{
+				Index:     i,
+				Path:      accountPath.Child(chain, i).String(),
+				Address:   address,
+				PublicKey: fmt.Sprintf("%x", pub),
+			})
+		}
+	}
+
+	switch *format {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "INDEX\tPATH\tADDRESS\tPUBLIC KEY")
+		for _, e := range entries {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", e.Index, e.Path, e.Address, e.PublicKey)
+		}
+		return w.Flush()
+
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"index", "path", "address", "public_key"})
+		for _, e := range entries {
+			w.Write([]string{strconv.FormatUint(uint64(e.Index), 10), e.Path, e.Address, e.PublicKey})
+		}
+		w.Flush()
+		return w.Error()
+
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	}
+
+	return fmt.Errorf("unknown format: %s", *format)
+}
+
+func addressesAccountPath(purpose uint32, network btools.Network, account uint32) btools.DerivationPath {
+	return btools.DerivationPath{
+		purpose + btools.HardenedKeyStart,
+		network.CoinType + btools.HardenedKeyStart,
+		account + btools.HardenedKeyStart,
+	}
+}
+
+// addressesAccountKey returns the account public key and its path from a
+// master private key or from an account level extended key. The path of an
+// account key takes the account index from the key itself.
+func addressesAccountKey(key btools.ExtendedKey, purpose uint32, network btools.Network, account uint32) (btools.XPubKey, btools.DerivationPath, error) {
+	path := addressesAccountPath(purpose, network, account)
+
+	switch k := key.(type) {
+	case btools.XPrivKey:
+		if k.Depth == 3 {
+			path[2] = k.Index
+			return k.XPubKey(), path, nil
+		}
+
+		if k.Depth != 0 {
+			return btools.XPubKey{}, nil, fmt.Errorf("expected a master or account private key, got depth %d", k.Depth)
+		}
+
+		accountKey, err := k.DerivePath(path)
+		if err != nil {
+			return btools.XPubKey{}, nil, err
+		}
+
+		return accountKey.XPubKey(), path, nil
+
+	case btools.XPubKey:
+		if k.Depth != 3 {
+			return btools.XPubKey{}, nil, fmt.Errorf("expected an account public key, got depth %d", k.Depth)
+		}
+
+		path[2] = k.Index
+		return k, path, nil
+	}
+
+	return btools.XPubKey{}, nil, fmt.Errorf("unsupported key type %T", key)
+}
+
+// addressesCheckKeyVersions warns when the SLIP-132 versions of the input
+// key, e.g. zpub, are those of a purpose other than the listed one. xpub and
+// tpub are used for every purpose and never warned about.
+func addressesCheckKeyVersions(versions btools.KeyVersions, purpose uint32, network btools.Network) {
+	if versions.Name == btools.XPubVersions.Name || versions.Name == btools.TPubVersions.Name {
+		return
+	}
+
+	purposes := []uint32{}
+	for p, v := range network.PurposeKeyVersions {
+		if v.Name == versions.Name {
+			if p == purpose {
+				return
+			}
+			purposes = append(purposes, p)
+		}
+	}
+
+	if len(purposes) == 0 {
+		fmt.Fprintf(os.Stderr, "warning: %s keys are not used for single signature accounts of purpose %d\n", versions.Name, purpose)
+		return
+	}
+
+	slices.Sort(purposes)
+	fmt.Fprintf(os.Stderr, "warning: %s keys are used for purpose %d, listing addresses of purpose %d (use -purpose)\n", versions.Name, purposes[0], purpose)
+}
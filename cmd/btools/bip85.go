@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/artilugio0/btools"
+)
+
+func runBIP85(args []string) error {
+	fs := flag.NewFlagSet("bip85", flag.ExitOnError)
+	app := fs.String("app", "bip39", "application: bip39, wif, xprv, hex, base64 or base85")
+	index := fs.Uint("index", 0, "child index")
+	words := fs.Int("words", 12, "number of words of the bip39 application")
+	lang := fs.String("lang", string(btools.English), "language of the bip39 application")
+	numBytes := fs.Int("bytes", 64, "number of bytes of the hex application")
+	length := fs.Int("length", 20, "length of the base64 and base85 passwords")
+	passphrase := fs.String("passphrase", "", "passphrase of the root mnemonic")
+	xprvFlag := fs.String("xprv", "", "root extended private key, used instead of reading a mnemonic from stdin")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools bip85 [flags]")
+		fmt.Fprintln(fs.Output(), "derives BIP85 child entropy from the root mnemonic read from stdin, or from -xprv")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *index >= uint(btools.HardenedKeyStart) {
+		fs.Usage()
+		return fmt.Errorf("-index must be below %d", btools.HardenedKeyStart)
+	}
+
+	if *xprvFlag != "" && *passphrase != "" {
+		fs.Usage()
+		return fmt.Errorf("-passphrase only applies to a mnemonic, not to -xprv")
+	}
+
+	root, err := bip85Root(*xprvFlag, *passphrase)
+	if err != nil {
+		return err
+	}
+
+	i := uint32(*index)
+	switch *app {
+	case "bip39":
+		child, err := btools.BIP85Mnemonic(root, btools.Language(*lang), *words, i)
+		if err != nil {
+			return err
+		}
+		wl, err := btools.GetWordlist(btools.Language(*lang))
+		if err != nil {
+			return err
+		}
+		fmt.Println(wl.Join(child))
+
+	case "wif":
+		wif, err := btools.BIP85HDSeedWIF(root, i)
+		if err != nil {
+			return err
+		}
+		fmt.Println(wif)
+
+	case "xprv":
+		xprv, err := btools.BIP85XPrv(root, i)
+		if err != nil {
+			return err
+		}
+		serialized, err := xprv.SerializeKey(btools.Mainnet)
+		if err != nil {
+			return err
+		}
+		fmt.Println(serialized)
+
+	case "hex":
+		entropy, err := btools.BIP85Hex(root, *numBytes, i)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%x\n", entropy)
+
+	case "base64":
+		pwd, err := btools.BIP85PasswordBase64(root, *length, i)
+		if err != nil {
+			return err
+		}
+		fmt.Println(pwd)
+
+	case "base85":
+		pwd, err := btools.BIP85PasswordBase85(root, *length, i)
+		if err != nil {
+			return err
+		}
+		fmt.Println(pwd)
+
+	default:
+		return fmt.Errorf("unknown application: %s", *app)
+	}
+
+	return nil
+}
+
+// bip85Root returns the root key parsed from xprv, or derived from the
+// mnemonic read from stdin when xprv is empty.
+func bip85Root(xprv, passphrase string) (btools.XPrivKey, error) {
+	if xprv != "" {
+		key, _, err := btools.ParseExtendedKey(xprv)
+		if err != nil {
+			return btools.XPrivKey{}, err
+		}
+
+		root, ok := key.(btools.XPrivKey)
+		if !ok {
+			return btools.XPrivKey{}, fmt.Errorf("expected an extended private key")
+		}
+
+		return root, nil
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	mnemonic := []string{}
+	for scanner.Scan() {
+		mnemonic = append(mnemonic, strings.Fields(scanner.Text())...)
+	}
+	if err := scanner.Err(); err != nil {
+		return btools.XPrivKey{}, err
+	}
+
+	seed, err := btools.NewSeed(mnemonic, passphrase)
+	if err != nil {
+		return btools.XPrivKey{}, err
+	}
+
+	return btools.MasterPrivateKey(seed)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/artilugio0/btools"
+)
+
+func runConvert(args []string) error {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", "xpub", "target version: xpub, ypub, zpub, Ypub, Zpub, tpub, upub, vpub, Upub or Vpub")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools convert [-to version] [key...]")
+		fmt.Fprintln(fs.Output(), "re-serializes extended keys with other SLIP-132 version bytes; keys are read from stdin if not given")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	versions, err := btools.GetKeyVersions(*to)
+	if err != nil {
+		return err
+	}
+
+	keys := fs.Args()
+	if len(keys) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			keys = append(keys, strings.Fields(scanner.Text())...)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		converted, err := btools.ConvertExtendedKey(k, versions)
+		if err != nil {
+			return err
+		}
+		fmt.Println(converted)
+	}
+
+	return nil
+}
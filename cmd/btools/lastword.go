@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/artilugio0/btools"
+)
+
+func runLastWord(args []string) error {
+	fs := flag.NewFlagSet("lastword", flag.ExitOnError)
+	lang := fs.String("lang", string(btools.English), "wordlist language")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools lastword [-lang language] [word...]")
+		fmt.Fprintln(fs.Output(), "prints every final word that makes the checksum valid; words are read from stdin if not given")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	words := fs.Args()
+	if len(words) == 0 {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			words = append(words, strings.Fields(scanner.Text())...)
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+
+	candidates, err := btools.FinalWordsIn(btools.Language(*lang), words)
+	if err != nil {
+		return err
+	}
+
+	for _, w := range candidates {
+		fmt.Println(w)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"math"
@@ -11,7 +13,53 @@ import (
 	"github.com/artilugio0/btools"
 )
 
+var commands = map[string]func(args []string) error{
+	"generate":      runGenerate,
+	"lastword":      runLastWord,
+	"split":         runSplit,
+	"combine":       runCombine,
+	"bip85":         runBIP85,
+	"convert":       runConvert,
+	"addresses":     runAddresses,
+	"signmessage":   runSignMessage,
+	"verifymessage": runVerifyMessage,
+}
+
 func main() {
+	run := runGenerate
+	args := os.Args[1:]
+	if len(args) > 0 {
+		cmd, ok := commands[args[0]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown command: %s\n", args[0])
+			os.Exit(2)
+		}
+		run = cmd
+		args = args[1:]
+	}
+
+	if err := run(args); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	pathFlag := fs.String("path", "m/42", "derivation path of the child key")
+	networkFlag := fs.String("network", btools.Mainnet.Name, "network of the serialized keys")
+	fs.Parse(args)
+
+	path, err := btools.ParseDerivationPath(*pathFlag)
+	if err != nil {
+		return err
+	}
+
+	network, err := btools.GetNetwork(*networkFlag)
+	if err != nil {
+		return err
+	}
+
 	// TODO: check that the pk is less than the order of the EC
 	// TODO: only allow raw entropy for base 2, 4 and 8
 	rawEntropy := false
@@ -19,7 +67,6 @@ func main() {
 	usePassphrase := false
 	base := 2   // [2, 10]
 	words := 24 // 12 15 18 21 24
-	mainnet := true
 
 	wordsToInputBits := map[int]int{
 		12: 128,
@@ -32,7 +79,7 @@ func main() {
 	buf := bufio.NewReader(os.Stdin)
 	bits, ok := wordsToInputBits[words]
 	if !ok {
-		panic("invalid words count")
+		return fmt.Errorf("invalid words count")
 	}
 	bytes := bits / 8
 
@@ -45,7 +92,7 @@ func main() {
 			if err == io.EOF {
 				break
 			}
-			panic(err)
+			return err
 		}
 		s = strings.TrimSpace(s)
 
@@ -57,25 +104,24 @@ func main() {
 		var err error
 		inputEntropy, err = btools.DiceToBase(inputEntropy, base)
 		if err != nil {
-			panic(err)
+			return err
 		}
 	}
 
 	var entropy []byte
-	var err error
 	if rawEntropy {
 		if len(inputEntropy) != neededSymbols {
-			panic("input does not have the required length")
+			return fmt.Errorf("input does not have the required length")
 		}
 		entropy, err = btools.StringToEntropyRaw(inputEntropy, base, bits)
 	} else {
 		if len(inputEntropy) < neededSymbols {
-			panic("input does not have enough length to produce the required entropy")
+			return fmt.Errorf("input does not have enough length to produce the required entropy")
 		}
 		entropy, err = btools.StringToEntropyHash(inputEntropy)
 	}
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	passphrase := ""
@@ -83,14 +129,14 @@ func main() {
 		fmt.Print("Passphrase: ")
 		passphrase, err = buf.ReadString('\n')
 		if err != nil {
-			panic(err)
+			return err
 		}
 		passphrase = strings.TrimSpace(passphrase)
 	}
 
 	mnemonic, err := btools.Mnemonic(entropy[:bytes])
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	fmt.Println("Mnemonic seed phrasee: ")
@@ -101,7 +147,7 @@ func main() {
 
 	seed, err := btools.NewSeed(mnemonic, passphrase)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	fmt.Print("Seed: ")
@@ -112,7 +158,7 @@ func main() {
 
 	masterKey, err := btools.MasterPrivateKey(seed)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	fmt.Print("Master private key: ")
@@ -127,35 +173,57 @@ func main() {
 	}
 	fmt.Println("")
 
-	masterKey2Base58 := masterKey.SerializeKey(mainnet)
+	masterKey2Base58, err := masterKey.SerializeKey(network)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Master private key: %s\n", masterKey2Base58)
 
 	masterPublicKey := masterKey.XPubKey()
 
-	masterKeyPubBase58 := masterPublicKey.SerializeKey(mainnet)
+	masterKeyPubBase58, err := masterPublicKey.SerializeKey(network)
+	if err != nil {
+		return err
+	}
 	fmt.Printf("Master public key: %s\n", masterKeyPubBase58)
 
-	index := uint32(42)
-	childXPrivKey, err := masterKey.CKDpriv(index)
+	childXPrivKey, err := masterKey.DerivePath(path)
+	if err != nil {
+		return err
+	}
+
+	cKeyBase58, err := childXPrivKey.SerializeKey(network)
 	if err != nil {
-		panic(err)
+		return err
 	}
+	fmt.Printf("Child private key (%s): %s\n", path, cKeyBase58)
 
-	cKeyBase58 := childXPrivKey.SerializeKey(mainnet)
-	fmt.Printf("Child private key: %s\n", cKeyBase58)
+	cKeyWIF, err := childXPrivKey.WIF(network)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Child private key WIF (%s): %s\n", path, cKeyWIF)
 
-	childPub, err := masterKey.CKDpub(index)
+	childPub := childXPrivKey.XPubKey()
+	cPubKeyBase58, err := childPub.SerializeKey(network)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	cPubKeyBase58 := childPub.SerializeKey(mainnet)
 
-	fmt.Printf("Child public key: %s\n", cPubKeyBase58)
+	fmt.Printf("Child public key (%s): %s\n", path, cPubKeyBase58)
 
-	childPub2, err := masterPublicKey.CKDpub(index)
+	childPub2, err := masterPublicKey.DerivePath(path)
+	if errors.Is(err, btools.ErrHardenedPublicDerivation) {
+		return nil
+	}
 	if err != nil {
-		panic(err)
+		return err
 	}
-	cPubKey2Base58 := childPub2.SerializeKey(mainnet)
-	fmt.Printf("Child public key 2: %s\n", cPubKey2Base58)
+	cPubKey2Base58, err := childPub2.SerializeKey(network)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Child public key 2 (%s): %s\n", path, cPubKey2Base58)
+
+	return nil
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/artilugio0/btools"
+)
+
+func runSignMessage(args []string) error {
+	fs := flag.NewFlagSet("signmessage", flag.ExitOnError)
+	pathFlag := fs.String("path", "", "derivation path of the signing key (default the first receive address of purpose 84)")
+	typeFlag := fs.String("type", "", "address type: p2pkh, p2sh-p2wpkh or p2wpkh (default from the purpose of the path)")
+	networkFlag := fs.String("network", "", "network of the address (default mainnet, or the network of an extended key)")
+	passphrase := fs.String("passphrase", "", "passphrase of the mnemonic")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools signmessage [flags] message")
+		fmt.Fprintln(fs.Output(), "signs a message (BIP137) with a key derived from the mnemonic or master xprv read from stdin")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected the message as the only argument")
+	}
+	msg := fs.Arg(0)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	input := []string{}
+	for scanner.Scan() {
+		input = append(input, strings.Fields(scanner.Text())...)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	network := btools.Mainnet
+	var err error
+	if *networkFlag != "" {
+		network, err = btools.GetNetwork(*networkFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	var root btools.XPrivKey
+	if len(input) == 1 {
+		key, versions, err := btools.ParseExtendedKey(input[0])
+		if err != nil {
+			return err
+		}
+
+		xprv, ok := key.(btools.XPrivKey)
+		if !ok {
+			return fmt.Errorf("expected an extended private key")
+		}
+
+		if *networkFlag == "" {
+			network, err = btools.NetworkForKeyVersions(versions)
+			if err != nil {
+				return fmt.Errorf("%w (use -network)", err)
+			}
+		}
+		root = xprv
+	} else {
+		seed, err := btools.NewSeed(input, *passphrase)
+		if err != nil {
+			return err
+		}
+
+		root, err = btools.MasterPrivateKey(seed)
+		if err != nil {
+			return err
+		}
+	}
+
+	path := addressesAccountPath(84, network, 0).Child(0, 0)
+	if *pathFlag != "" {
+		path, err = btools.ParseDerivationPath(*pathFlag)
+		if err != nil {
+			return err
+		}
+	}
+
+	addrType, err := messageAddressType(*typeFlag, path)
+	if err != nil {
+		return err
+	}
+
+	key, err := root.DerivePath(path)
+	if err != nil {
+		return err
+	}
+
+	address, err := key.XPubKey().Address(addrType, network)
+	if err != nil {
+		return err
+	}
+
+	sig, err := btools.SignMessage(key.PrivateKey, addrType, msg)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s\n", address)
+	fmt.Printf("Signature: %s\n", sig)
+
+	return nil
+}
+
+// messageAddressType returns the address type named by the type flag or, if
+// it is empty, the one of the purpose of path.
+func messageAddressType(name string, path btools.DerivationPath) (btools.AddressType, error) {
+	if name != "" {
+		return btools.ParseAddressType(name)
+	}
+
+	if len(path) == 0 || path[0] < btools.HardenedKeyStart {
+		return 0, fmt.Errorf("cannot infer the address type from path %s, use -type", path)
+	}
+
+	return btools.AddressTypeForPurpose(path[0] - btools.HardenedKeyStart)
+}
+
+func runVerifyMessage(args []string) error {
+	fs := flag.NewFlagSet("verifymessage", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools verifymessage address signature message")
+		fmt.Fprintln(fs.Output(), "verifies a BIP137 signed message, including Electrum and Trezor signatures of SegWit addresses")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 3 {
+		fs.Usage()
+		return fmt.Errorf("expected an address, a signature and a message")
+	}
+
+	ok, err := btools.VerifyMessage(fs.Arg(0), fs.Arg(2), fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("the signature is not valid for the address and message")
+	}
+
+	fmt.Println("Signature is valid")
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/artilugio0/btools"
+)
+
+func runSplit(args []string) error {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	secretHex := fs.String("secret", "", "master secret in hex, read from stdin if empty")
+	groupThreshold := fs.Int("threshold", 1, "number of groups required to recover the secret")
+	groupsSpec := fs.String("groups", "1-of-1", "comma separated member thresholds and counts of each group, e.g. 2-of-3,3-of-5")
+	passphrase := fs.String("passphrase", "", "passphrase used to encrypt the master secret")
+	iterationExponent := fs.Int("exp", 1, "iteration exponent")
+	extendable := fs.Bool("extendable", true, "create extendable backup shares")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools split [flags]")
+		fmt.Fprintln(fs.Output(), "splits a master secret into SLIP-39 mnemonic shares")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *secretHex == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return err
+		}
+		*secretHex = strings.TrimSpace(line)
+	}
+
+	secret, err := hex.DecodeString(*secretHex)
+	if err != nil {
+		return fmt.Errorf("invalid master secret: %w", err)
+	}
+
+	groups := []btools.SLIP39Group{}
+	for _, spec := range strings.Split(*groupsSpec, ",") {
+		g := btools.SLIP39Group{}
+		if _, err := fmt.Sscanf(strings.TrimSpace(spec), "%d-of-%d", &g.Threshold, &g.Count); err != nil {
+			return fmt.Errorf("invalid group %q: %w", spec, err)
+		}
+		groups = append(groups, g)
+	}
+
+	shares, err := btools.SLIP39Split(secret, *passphrase, *groupThreshold, groups, *extendable, *iterationExponent)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d of %d groups are required to recover the secret\n", *groupThreshold, len(groups))
+	for i, group := range shares {
+		fmt.Println("")
+		fmt.Printf("Group %d (%d of %d shares required):\n", i+1, groups[i].Threshold, groups[i].Count)
+		for j, mnemonic := range group {
+			fmt.Printf("%d) %s\n", j+1, strings.Join(mnemonic, " "))
+		}
+	}
+
+	return nil
+}
+
+func runCombine(args []string) error {
+	fs := flag.NewFlagSet("combine", flag.ExitOnError)
+	passphrase := fs.String("passphrase", "", "passphrase used to encrypt the master secret")
+	networkFlag := fs.String("network", btools.Mainnet.Name, "network of the serialized master key")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: btools combine [flags]")
+		fmt.Fprintln(fs.Output(), "recovers the master secret from SLIP-39 mnemonic shares read from stdin, one per line")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	mnemonics := [][]string{}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) > 0 {
+			mnemonics = append(mnemonics, words)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	network, err := btools.GetNetwork(*networkFlag)
+	if err != nil {
+		return err
+	}
+
+	seed, err := btools.SLIP39Seed(mnemonics, *passphrase)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Master secret: %x\n", seed.Seed)
+
+	masterKey, err := btools.MasterPrivateKey(seed)
+	if err != nil {
+		return err
+	}
+
+	masterKeyBase58, err := masterKey.SerializeKey(network)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Master private key: %s\n", masterKeyBase58)
+
+	return nil
+}
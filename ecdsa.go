@@ -0,0 +1,363 @@
+package btools
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Signature is an ECDSA signature over secp256k1.
+type Signature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// Sign signs hash with the private key priv using a deterministic nonce as
+// specified in RFC 6979 with HMAC-SHA256. The returned signature has a low S
+// as required by the Bitcoin standardness rules.
+func Sign(priv *big.Int, hash []byte) (Signature, error) {
+	sig, _, err := signRecoverable(priv, hash)
+	return sig, err
+}
+
+// signRecoverable works as Sign and also returns the recovery id of the
+// signature: bit 0 is the parity of the y coordinate of R and bit 1 is set
+// when its x coordinate is not below the curve order.
+func signRecoverable(priv *big.Int, hash []byte) (Signature, byte, error) {
+	if priv == nil || priv.Sign() <= 0 || priv.Cmp(secp256k1Order) >= 0 {
+		return Signature{}, 0, fmt.Errorf("invalid private key: out of range")
+	}
+
+	// the arithmetic on the key and the nonce uses scalars, which run in
+	// constant time, math/big is only used for public values
+	d := scalarFromBig(priv)
+	e := scalarFromBig(hashToInt(hash))
+	nonces := newRFC6979(d, e)
+	for {
+		k := nonces.next()
+
+		R := mulGenerator(k).point()
+		r := scalarFromBig(R.X)
+		if r.isZero() == 1 {
+			continue
+		}
+
+		// s = k^-1 * (e + r*d) mod n
+		s := k.inverse().mul(r.mul(d).add(e))
+		if s.isZero() == 1 {
+			continue
+		}
+
+		recID := byte(R.Y.Bit(0))
+		if R.X.Cmp(secp256k1Order) >= 0 {
+			recID |= 2
+		}
+
+		// negating s is the same as negating R
+		high := s.isHigh()
+		s = s.choose(s.neg(), high)
+		recID ^= byte(high)
+
+		return Signature{R: r.big(), S: s.big()}, recID, nil
+	}
+}
+
+// Verify checks the ECDSA signature sig of hash for the public key pub. Both
+// low and high S values are accepted, use IsLowS to reject the latter.
+func Verify(pub Point, hash []byte, sig Signature) bool {
+	if sig.R == nil || sig.S == nil ||
+		sig.R.Sign() <= 0 || sig.R.Cmp(secp256k1Order) >= 0 ||
+		sig.S.Sign() <= 0 || sig.S.Cmp(secp256k1Order) >= 0 {
+		return false
+	}
+
+	if !pub.IsOnCurve() {
+		return false
+	}
+
+	e := hashToInt(hash)
+	w := big.NewInt(0).ModInverse(sig.S, secp256k1Order)
+	u1 := big.NewInt(0).Mul(e, w)
+	u1.Mod(u1, secp256k1Order)
+	u2 := big.NewInt(0).Mul(sig.R, w)
+	u2.Mod(u2, secp256k1Order)
+
+	R := mulSumVartime(
+		[]scalar{scalarFromBig(u1), scalarFromBig(u2)},
+		[]jacobianPoint{jacobianFromPoint(g), jacobianFromPoint(pub)},
+	).point()
+	if R.IsInfinity() {
+		return false
+	}
+
+	return big.NewInt(0).Mod(R.X, secp256k1Order).Cmp(sig.R) == 0
+}
+
+// IsLowS tells whether S is at most half the curve order.
+func (sig Signature) IsLowS() bool {
+	halfOrder := big.NewInt(0).Rsh(secp256k1Order, 1)
+	return sig.S.Cmp(halfOrder) <= 0
+}
+
+// Normalize returns the equivalent signature with a low S.
+func (sig Signature) Normalize() Signature {
+	if sig.IsLowS() {
+		return sig
+	}
+
+	return Signature{
+		R: sig.R,
+		S: big.NewInt(0).Sub(secp256k1Order, sig.S),
+	}
+}
+
+// SerializeDER encodes the signature in strict DER as required by BIP66.
+func (sig Signature) SerializeDER() []byte {
+	r := derInteger(sig.R)
+	s := derInteger(sig.S)
+
+	result := []byte{0x30, byte(4 + len(r) + len(s))}
+	result = append(result, 0x02, byte(len(r)))
+	result = append(result, r...)
+	result = append(result, 0x02, byte(len(s)))
+	result = append(result, s...)
+
+	return result
+}
+
+func derInteger(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) == 0 || b[0]&0x80 != 0 {
+		b = append([]byte{0x00}, b...)
+	}
+
+	return b
+}
+
+// ParseDERSignature decodes a strict DER encoded signature, without a
+// sighash type byte.
+func ParseDERSignature(data []byte) (Signature, error) {
+	if len(data) < 8 || len(data) > 72 {
+		return Signature{}, fmt.Errorf("%w: invalid DER length %d", ErrInvalidSignature, len(data))
+	}
+
+	if data[0] != 0x30 || int(data[1]) != len(data)-2 {
+		return Signature{}, fmt.Errorf("%w: invalid DER sequence", ErrInvalidSignature)
+	}
+
+	r, rest, err := parseDERInteger(data[2:])
+	if err != nil {
+		return Signature{}, err
+	}
+
+	s, rest, err := parseDERInteger(rest)
+	if err != nil {
+		return Signature{}, err
+	}
+
+	if len(rest) != 0 {
+		return Signature{}, fmt.Errorf("%w: trailing data after DER signature", ErrInvalidSignature)
+	}
+
+	return Signature{R: r, S: s}, nil
+}
+
+func parseDERInteger(data []byte) (*big.Int, []byte, error) {
+	if len(data) < 2 || data[0] != 0x02 {
+		return nil, nil, fmt.Errorf("%w: expected DER integer", ErrInvalidSignature)
+	}
+
+	length := int(data[1])
+	if length == 0 || length > 33 || len(data) < 2+length {
+		return nil, nil, fmt.Errorf("%w: invalid DER integer length", ErrInvalidSignature)
+	}
+
+	value := data[2 : 2+length]
+	if value[0]&0x80 != 0 {
+		return nil, nil, fmt.Errorf("%w: negative DER integer", ErrInvalidSignature)
+	}
+
+	if length > 1 && value[0] == 0x00 && value[1]&0x80 == 0 {
+		return nil, nil, fmt.Errorf("%w: DER integer with excess padding", ErrInvalidSignature)
+	}
+
+	return big.NewInt(0).SetBytes(value), data[2+length:], nil
+}
+
+// SerializeCompact encodes the signature as the 64 bytes r || s.
+func (sig Signature) SerializeCompact() []byte {
+	result := make([]byte, 64)
+	sig.R.FillBytes(result[:32])
+	sig.S.FillBytes(result[32:])
+
+	return result
+}
+
+// ParseCompactSignature decodes a 64 bytes r || s signature.
+func ParseCompactSignature(data []byte) (Signature, error) {
+	if len(data) != 64 {
+		return Signature{}, fmt.Errorf("%w: invalid compact signature length %d", ErrInvalidSignature, len(data))
+	}
+
+	return Signature{
+		R: big.NewInt(0).SetBytes(data[:32]),
+		S: big.NewInt(0).SetBytes(data[32:]),
+	}, nil
+}
+
+// SignCompact signs hash as Sign and returns the 65 bytes signature
+// header || r || s from which the public key can be recovered. The header is
+// 27 plus the recovery id, plus 4 if the public key is compressed.
+func SignCompact(priv *big.Int, hash []byte, compressed bool) ([]byte, error) {
+	sig, recID, err := signRecoverable(priv, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	header := 27 + recID
+	if compressed {
+		header += 4
+	}
+
+	return append([]byte{header}, sig.SerializeCompact()...), nil
+}
+
+// RecoverCompact recovers the public key from a signature produced by
+// SignCompact, and tells whether the key is compressed.
+func RecoverCompact(sig, hash []byte) (Point, bool, error) {
+	if len(sig) != 65 {
+		return Point{}, false, fmt.Errorf("%w: invalid compact signature length %d", ErrInvalidSignature, len(sig))
+	}
+
+	header := sig[0]
+	if header < 27 || header > 34 {
+		return Point{}, false, fmt.Errorf("%w: invalid recovery header %d", ErrInvalidSignature, header)
+	}
+
+	compressed := header >= 31
+	recID := (header - 27) & 3
+
+	s, err := ParseCompactSignature(sig[1:])
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	pub, err := RecoverPublicKey(hash, s, recID)
+	if err != nil {
+		return Point{}, false, err
+	}
+
+	return pub, compressed, nil
+}
+
+// RecoverPublicKey returns the public key for which sig is a valid signature
+// of hash, given the recovery id of the signature.
+func RecoverPublicKey(hash []byte, sig Signature, recID byte) (Point, error) {
+	if recID > 3 {
+		return Point{}, fmt.Errorf("%w: invalid recovery id %d", ErrInvalidSignature, recID)
+	}
+
+	if sig.R == nil || sig.S == nil ||
+		sig.R.Sign() <= 0 || sig.R.Cmp(secp256k1Order) >= 0 ||
+		sig.S.Sign() <= 0 || sig.S.Cmp(secp256k1Order) >= 0 {
+		return Point{}, fmt.Errorf("%w: r or s out of range", ErrInvalidSignature)
+	}
+
+	// R has x = r + j*n, with j the bit 1 of the recovery id
+	x := big.NewInt(0).Set(sig.R)
+	if recID&2 != 0 {
+		x.Add(x, secp256k1Order)
+	}
+
+	if x.Cmp(p) >= 0 {
+		return Point{}, fmt.Errorf("%w: x coordinate of R out of range", ErrInvalidSignature)
+	}
+
+	R, err := decompressPoint(append([]byte{0x02 | recID&1}, x.FillBytes(make([]byte, 32))...))
+	if err != nil {
+		return Point{}, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	// Q = r^-1 * (s*R - e*G)
+	rInv := big.NewInt(0).ModInverse(sig.R, secp256k1Order)
+	u1 := big.NewInt(0).Mul(hashToInt(hash), rInv)
+	u1.Neg(u1).Mod(u1, secp256k1Order)
+	u2 := big.NewInt(0).Mul(sig.S, rInv)
+	u2.Mod(u2, secp256k1Order)
+
+	Q := mulSumVartime(
+		[]scalar{scalarFromBig(u1), scalarFromBig(u2)},
+		[]jacobianPoint{jacobianFromPoint(g), jacobianFromPoint(R)},
+	).point()
+	if Q.IsInfinity() {
+		return Point{}, fmt.Errorf("%w: recovered the %w", ErrInvalidSignature, ErrPointAtInfinity)
+	}
+
+	return Q, nil
+}
+
+// hashToInt converts a message hash to an integer as the bits2int function
+// of RFC 6979, keeping the leftmost 256 bits.
+func hashToInt(hash []byte) *big.Int {
+	if len(hash) > 32 {
+		hash = hash[:32]
+	}
+
+	return big.NewInt(0).SetBytes(hash)
+}
+
+// rfc6979 generates the sequence of candidate nonces of RFC 6979 section
+// 3.2 for secp256k1 and HMAC-SHA256.
+type rfc6979 struct {
+	k     []byte
+	v     []byte
+	first bool
+}
+
+func newRFC6979(d, e scalar) *rfc6979 {
+	x := d.bytes()
+	h := e.bytes()
+
+	g := &rfc6979{
+		k:     make([]byte, 32),
+		v:     bytes.Repeat([]byte{0x01}, 32),
+		first: true,
+	}
+
+	g.k = g.mac(g.v, []byte{0x00}, x, h)
+	g.v = g.mac(g.v)
+	g.k = g.mac(g.v, []byte{0x01}, x, h)
+	g.v = g.mac(g.v)
+
+	return g
+}
+
+func (g *rfc6979) mac(data ...[]byte) []byte {
+	hm := hmac.New(sha256.New, g.k)
+	for _, d := range data {
+		hm.Write(d)
+	}
+
+	return hm.Sum(nil)
+}
+
+// next returns the next nonce in [1, n-1].
+func (g *rfc6979) next() scalar {
+	for {
+		if !g.first {
+			g.k = g.mac(g.v, []byte{0x00})
+			g.v = g.mac(g.v)
+		}
+		g.first = false
+
+		g.v = g.mac(g.v)
+		if k, ok := scalarFromCanonicalBytes(g.v); ok == 1 {
+			return k
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package btools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+)
+
+func hexInt(s string) *big.Int {
+	n, ok := big.NewInt(0).SetString(s, 16)
+	if !ok {
+		panic("invalid hex integer: " + s)
+	}
+
+	return n
+}
+
+// RFC 6979 deterministic signatures for secp256k1 and SHA-256, as used by
+// python-ecdsa and Trezor, with low S.
+var rfc6979Vectors = []struct {
+	priv string
+	msg  string
+	r, s string
+}{
+	{
+		priv: "1",
+		msg:  "Satoshi Nakamoto",
+		r:    "934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8",
+		s:    "2442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5",
+	},
+	{
+		priv: "1",
+		msg:  "All those moments will be lost in time, like tears in rain. Time to die...",
+		r:    "8600dbd41e348fe5c9465ab92d23e3db8b98b873beecd930736488696438cb6b",
+		s:    "547fe64427496db33bf66019dacbf0039c04199abb0122918601db38a72cfc21",
+	},
+	{
+		priv: "fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364140",
+		msg:  "Satoshi Nakamoto",
+		r:    "fd567d121db66e382991534ada77a6bd3106f0a1098c231e47993447cd6af2d0",
+		s:    "6b39cd0eb1bc8603e159ef5c20a5c8ad685a45b06ce9bebed3f153d10d93bed5",
+	},
+	{
+		priv: "f8b8af8ce3c7cca5e300d33939540c10d45ce001b8f252bfbc57ba0342904181",
+		msg:  "Alan Turing",
+		r:    "7063ae83e7f62bbb171798131b4a0564b956930092b33b07b395615d9ec7e15c",
+		s:    "58dfcc1e00a35e1572f366ffe34ba0fc47db1e7189759b9fb233c5b05ab388ea",
+	},
+	{
+		priv: "e91671c46231f833a6406ccbea0e3e392c76c167bac1cb013f6f1013980455c2",
+		msg:  "There is a computer disease that anybody who works with computers knows about. It's a very serious disease and it interferes completely with the work. The trouble with computers is that you 'play' with them!",
+		r:    "b552edd27580141f3b2a5463048cb7cd3e047b97c9f98076c32dbdf85a68718b",
+		s:    "279fa72dd19bfae05577e06c7c0c1900c371fcd5893f7e1d56a37d30174671f6",
+	},
+}
+
+func TestSignRFC6979(t *testing.T) {
+	for _, v := range rfc6979Vectors {
+		priv := hexInt(v.priv)
+		hash := sha256.Sum256([]byte(v.msg))
+
+		sig, err := Sign(priv, hash[:])
+		if err != nil {
+			t.Fatalf("Sign(%s, %q): %v", v.priv, v.msg, err)
+		}
+
+		if sig.R.Cmp(hexInt(v.r)) != 0 || sig.S.Cmp(hexInt(v.s)) != 0 {
+			t.Errorf("Sign(%s, %q) = (%x, %x), want (%s, %s)", v.priv, v.msg, sig.R, sig.S, v.r, v.s)
+		}
+
+		if !Verify(Secp256k1Pub(priv), hash[:], sig) {
+			t.Errorf("Verify(%s, %q) failed", v.priv, v.msg)
+		}
+	}
+}
+
+func TestSignInvalidKey(t *testing.T) {
+	hash := make([]byte, 32)
+	for _, priv := range []*big.Int{nil, big.NewInt(0), big.NewInt(-1), secp256k1Order} {
+		if _, err := Sign(priv, hash); err == nil {
+			t.Errorf("Sign(%v) succeeded", priv)
+		}
+	}
+}
+
+func TestRecoverCompact(t *testing.T) {
+	for _, v := range rfc6979Vectors {
+		priv := hexInt(v.priv)
+		hash := sha256.Sum256([]byte(v.msg))
+
+		for _, compressed := range []bool{false, true} {
+			sig, err := SignCompact(priv, hash[:], compressed)
+			if err != nil {
+				t.Fatalf("SignCompact(%s, %q): %v", v.priv, v.msg, err)
+			}
+
+			pub, gotCompressed, err := RecoverCompact(sig, hash[:])
+			if err != nil || !pub.Equal(Secp256k1Pub(priv)) || gotCompressed != compressed {
+				t.Errorf("RecoverCompact of SignCompact(%s, %q, %v) = %v, %v", v.priv, v.msg, compressed, gotCompressed, err)
+			}
+
+			// another hash recovers another key, if any
+			other := sha256.Sum256([]byte(v.msg + "."))
+			if pub, _, err := RecoverCompact(sig, other[:]); err == nil && pub.Equal(Secp256k1Pub(priv)) {
+				t.Errorf("RecoverCompact(%s, %q) recovered the key for another hash", v.priv, v.msg)
+			}
+		}
+	}
+
+	// the signatures of message_test.go, made with btcd
+	wif, err := DecodeWIF(messageWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range messageVectors {
+		sig, _ := base64.StdEncoding.DecodeString(v.sig)
+		if sig[0] >= 35 {
+			continue
+		}
+
+		pub, compressed, err := RecoverCompact(sig, SignedMessageHash(v.msg))
+		if err != nil || !pub.Equal(Secp256k1Pub(wif.PrivateKey)) || compressed != (sig[0] >= 31) {
+			t.Errorf("RecoverCompact(%s) = %v, %v", v.sig, compressed, err)
+		}
+	}
+
+	sig, _ := base64.StdEncoding.DecodeString(messageVectors[1].sig)
+	hash := SignedMessageHash(messageVectors[1].msg)
+
+	invalid := map[string][]byte{
+		"short":         sig[:64],
+		"long":          append(bytes.Clone(sig), 0),
+		"header 26":     append([]byte{26}, sig[1:]...),
+		"header 35":     append([]byte{35}, sig[1:]...),
+		"zero r":        append(append([]byte{31}, make([]byte, 32)...), sig[33:]...),
+		"zero s":        append(bytes.Clone(sig[:33]), make([]byte, 32)...),
+		"recovery id 2": append([]byte{33}, sig[1:]...),
+	}
+
+	for name, s := range invalid {
+		if _, _, err := RecoverCompact(s, hash); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("RecoverCompact of %s returned %v, want ErrInvalidSignature", name, err)
+		}
+	}
+}
+
+type wycheproofECDSA struct {
+	TestGroups []struct {
+		PublicKey struct {
+			Uncompressed string `json:"uncompressed"`
+		} `json:"publicKey"`
+		Tests []struct {
+			TcID    int    `json:"tcId"`
+			Comment string `json:"comment"`
+			Msg     string `json:"msg"`
+			Sig     string `json:"sig"`
+			Result  string `json:"result"`
+		} `json:"tests"`
+	} `json:"testGroups"`
+}
+
+// The Wycheproof vectors of testdata/wycheproof come from
+// https://github.com/C2SP/wycheproof (testvectors_v1). The bitcoin variant
+// also requires a low S, and p1363 encodes signatures as r || s.
+func TestWycheproofECDSA(t *testing.T) {
+	tests := []struct {
+		file  string
+		parse func([]byte) (Signature, error)
+		lowS  bool
+	}{
+		{"ecdsa_secp256k1_sha256_test.json", ParseDERSignature, false},
+		{"ecdsa_secp256k1_sha256_bitcoin_test.json", ParseDERSignature, true},
+		{"ecdsa_secp256k1_sha256_p1363_test.json", ParseCompactSignature, false},
+	}
+
+	for _, tt := range tests {
+		data, err := os.ReadFile("testdata/wycheproof/" + tt.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var vectors wycheproofECDSA
+		if err := json.Unmarshal(data, &vectors); err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+
+		for _, group := range vectors.TestGroups {
+			pubBytes, _ := hex.DecodeString(group.PublicKey.Uncompressed)
+			pub, err := ParsePublicKey(pubBytes)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.file, err)
+			}
+
+			for _, tc := range group.Tests {
+				// acceptable cases may go either way
+				if tc.Result == "acceptable" {
+					continue
+				}
+
+				msg, _ := hex.DecodeString(tc.Msg)
+				sigBytes, _ := hex.DecodeString(tc.Sig)
+				hash := sha256.Sum256(msg)
+
+				valid := false
+				sig, err := tt.parse(sigBytes)
+				if err == nil {
+					valid = Verify(pub, hash[:], sig) && (!tt.lowS || sig.IsLowS())
+				}
+
+				if valid != (tc.Result == "valid") {
+					t.Errorf("%s: test %d (%s): valid %v, want %s", tt.file, tc.TcID, tc.Comment, valid, tc.Result)
+				}
+			}
+		}
+	}
+}
+
+func TestSignatureEncoding(t *testing.T) {
+	for range 50 {
+		privBytes := make([]byte, 32)
+		rand.Read(privBytes)
+		priv := big.NewInt(0).Mod(big.NewInt(0).SetBytes(privBytes), secp256k1Order)
+		if priv.Sign() == 0 {
+			continue
+		}
+
+		hash := make([]byte, 32)
+		rand.Read(hash)
+
+		sig, err := Sign(priv, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !sig.IsLowS() {
+			t.Errorf("Sign returned a high S")
+		}
+
+		der := sig.SerializeDER()
+		parsed, err := ParseDERSignature(der)
+		if err != nil {
+			t.Fatalf("ParseDERSignature(%x): %v", der, err)
+		}
+
+		if parsed.R.Cmp(sig.R) != 0 || parsed.S.Cmp(sig.S) != 0 || !bytes.Equal(parsed.SerializeDER(), der) {
+			t.Errorf("DER round trip of %x failed", der)
+		}
+
+		compact := sig.SerializeCompact()
+		parsed, err = ParseCompactSignature(compact)
+		if err != nil {
+			t.Fatalf("ParseCompactSignature(%x): %v", compact, err)
+		}
+
+		if parsed.R.Cmp(sig.R) != 0 || parsed.S.Cmp(sig.S) != 0 {
+			t.Errorf("compact round trip of %x failed", compact)
+		}
+
+		pub := Secp256k1Pub(priv)
+		for _, compressed := range []bool{false, true} {
+			recoverable, err := SignCompact(priv, hash, compressed)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(recoverable[1:], compact) {
+				t.Errorf("SignCompact signature differs from Sign")
+			}
+
+			recovered, c, err := RecoverCompact(recoverable, hash)
+			if err != nil || !recovered.Equal(pub) || c != compressed {
+				t.Errorf("RecoverCompact(%x) = %v, %v, %v", recoverable, recovered, c, err)
+			}
+		}
+
+		high := Signature{R: sig.R, S: big.NewInt(0).Sub(secp256k1Order, sig.S)}
+		if high.IsLowS() || !Verify(pub, hash, high) || high.Normalize().S.Cmp(sig.S) != 0 {
+			t.Errorf("high S signature is not handled")
+		}
+
+		hash[0] ^= 1
+		if Verify(pub, hash, sig) {
+			t.Errorf("signature verified for another hash")
+		}
+	}
+}
+
+func TestParseDERSignatureErrors(t *testing.T) {
+	valid := "3045022100934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d802202442ce9d2b916064108014783e923ec36b49743e2ffa1c4496f01a512aafd9e5"
+
+	tests := []struct {
+		name string
+		sig  string
+	}{
+		{"empty", ""},
+		{"wrong tag", "31" + valid[2:]},
+		{"wrong length", "3046" + valid[4:]},
+		{"trailing data", valid + "00"},
+		{"truncated", valid[:len(valid)-2]},
+		{"negative r", "3044022093" + valid[12:]},
+		{"padded r", "30070202007f020101"},
+		{"long r", "304602220000" + valid[10:]},
+	}
+
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.sig)
+		if _, err := ParseDERSignature(data); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: error %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+
+	// well formed but out of range values are rejected by Verify
+	zeroS, _ := hex.DecodeString("3026022100934b1ea10a4b3c1757e2b0c017d0b6143ce3c9a7e6a4a49860d7a6ab210ee3d8020100")
+	sig, err := ParseDERSignature(zeroS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Verify(Secp256k1Pub(big.NewInt(1)), make([]byte, 32), sig) {
+		t.Errorf("signature with a zero S verified")
+	}
+}
+
+func BenchmarkECDSASign(b *testing.B) {
+	hash := make([]byte, 32)
+	for range b.N {
+		Sign(benchmarkKey, hash)
+	}
+}
+
+func BenchmarkECDSAVerify(b *testing.B) {
+	hash := make([]byte, 32)
+	sig, _ := Sign(benchmarkKey, hash)
+	pub := Secp256k1Pub(benchmarkKey)
+	for range b.N {
+		Verify(pub, hash, sig)
+	}
+}
@@ -1,26 +1,40 @@
 package btools
 
 import (
+	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"math/big"
 	"strings"
 )
 
-func Base58Check(input []byte) string {
-	bytes := append([]byte{}, input...)
+const base58Symbols = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
 
-	checksum := sha256.Sum256(input)
-	checksum = sha256.Sum256(checksum[:])
+var (
+	ErrBase58Checksum = errors.New("invalid base58check checksum")
+	ErrBase58TooShort = errors.New("base58check data is too short")
+)
 
-	bytes = append(bytes, checksum[:4]...)
+type Base58CharacterError struct {
+	Position  int
+	Character rune
+}
+
+func (e Base58CharacterError) Error() string {
+	return fmt.Sprintf("invalid base58 character %q at position %d", e.Character, e.Position)
+}
 
+// Base58Encode encodes data in base58, each leading zero byte is encoded as a
+// leading '1'.
+func Base58Encode(data []byte) string {
 	zero := big.NewInt(0)
 	n := big.NewInt(0)
-	n = n.SetBytes(bytes)
+	n = n.SetBytes(data)
 
 	base := big.NewInt(58)
 
-	base58Symbols := "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
 	reminder := big.NewInt(0)
 	builder := strings.Builder{}
 	for n.Cmp(zero) > 0 {
@@ -28,6 +42,13 @@ func Base58Check(input []byte) string {
 		builder.WriteByte(base58Symbols[int(reminder.Int64())])
 	}
 
+	for _, b := range data {
+		if b != 0x00 {
+			break
+		}
+		builder.WriteByte(base58Symbols[0])
+	}
+
 	runes := []rune(builder.String())
 	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
 		runes[i], runes[j] = runes[j], runes[i]
@@ -35,3 +56,78 @@ func Base58Check(input []byte) string {
 
 	return string(runes)
 }
+
+func Base58Decode(s string) ([]byte, error) {
+	n := big.NewInt(0)
+	base := big.NewInt(58)
+	zeros := 0
+	leading := true
+	// range yields byte offsets, the error reports the index of the rune
+	position := 0
+	for _, c := range s {
+		d := strings.IndexRune(base58Symbols, c)
+		if d < 0 {
+			return nil, Base58CharacterError{Position: position, Character: c}
+		}
+		position++
+
+		if leading && d == 0 {
+			zeros++
+		} else {
+			leading = false
+		}
+
+		n.Mul(n, base).Add(n, big.NewInt(int64(d)))
+	}
+
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+func base58Checksum(input []byte) []byte {
+	checksum := sha256.Sum256(input)
+	checksum = sha256.Sum256(checksum[:])
+
+	return checksum[:4]
+}
+
+func Base58Check(input []byte) string {
+	bytes := append([]byte{}, input...)
+	bytes = append(bytes, base58Checksum(input)...)
+
+	return Base58Encode(bytes)
+}
+
+// Base58CheckDecode decodes a Base58Check string and returns the payload
+// without the checksum.
+func Base58CheckDecode(s string) ([]byte, error) {
+	data, err := Base58Decode(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 4 {
+		return nil, ErrBase58TooShort
+	}
+
+	payload, checksum := data[:len(data)-4], data[len(data)-4:]
+	if !bytes.Equal(base58Checksum(payload), checksum) {
+		return nil, ErrBase58Checksum
+	}
+
+	return payload, nil
+}
+
+// appendVarInt appends n to b in the Bitcoin variable length integer
+// encoding (CompactSize).
+func appendVarInt(b []byte, n uint64) []byte {
+	switch {
+	case n < 0xFD:
+		return append(b, byte(n))
+	case n <= 0xFFFF:
+		return binary.LittleEndian.AppendUint16(append(b, 0xFD), uint16(n))
+	case n <= 0xFFFFFFFF:
+		return binary.LittleEndian.AppendUint32(append(b, 0xFE), uint32(n))
+	}
+
+	return binary.LittleEndian.AppendUint64(append(b, 0xFF), n)
+}
@@ -0,0 +1,83 @@
+package btools
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// base58_encode_decode.json of Bitcoin Core
+var base58Vectors = []struct {
+	hex     string
+	encoded string
+}{
+	{"", ""},
+	{"61", "2g"},
+	{"626262", "a3gV"},
+	{"636363", "aPEr"},
+	{"73696d706c792061206c6f6e6720737472696e67", "2cFupjhnEsSn59qHXstmK2ffpLv2"},
+	{"00eb15231dfceb60925886b67d065299925915aeb172c06647", "1NS17iag9jJgTHD1VXjvLCEnZuQ3rJDE9L"},
+	{"516b6fcd0f", "ABnLTmg"},
+	{"bf4f89001e670274dd", "3SEo3LWLoPntC"},
+	{"572e4794", "3EFU7m"},
+	{"ecac89cad93923c02321", "EJDM8drfXA6uyA"},
+	{"10c8511e", "Rt5zm"},
+	{"00000000000000000000", "1111111111"},
+}
+
+func TestBase58(t *testing.T) {
+	for _, v := range base58Vectors {
+		data, _ := hex.DecodeString(v.hex)
+		if got := Base58Encode(data); got != v.encoded {
+			t.Errorf("Base58Encode(%s) = %s, want %s", v.hex, got, v.encoded)
+		}
+
+		got, err := Base58Decode(v.encoded)
+		if err != nil || !bytes.Equal(got, data) {
+			t.Errorf("Base58Decode(%s) = %x, %v, want %s", v.encoded, got, err, v.hex)
+		}
+	}
+
+	tests := []struct {
+		s        string
+		position int
+		c        rune
+	}{
+		{"0", 0, '0'},
+		{"3SEo3LWLoPntC ", 13, ' '},
+		{"3SEoIL", 4, 'I'},
+		{"3SEo€", 4, '€'},
+		{"ñ", 0, 'ñ'},
+	}
+
+	for _, tt := range tests {
+		var charErr Base58CharacterError
+		if _, err := Base58Decode(tt.s); !errors.As(err, &charErr) || charErr.Position != tt.position || charErr.Character != tt.c {
+			t.Errorf("Base58Decode(%q) returned %v, want %q at %d", tt.s, err, tt.c, tt.position)
+		}
+	}
+}
+
+func TestBase58Check(t *testing.T) {
+	// the payload of a P2PKH address, version 0 gives the leading 1
+	payload, _ := hex.DecodeString("00010966776006953d5567439e5e39f86a0d273bee")
+	address := "16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvM"
+
+	if got := Base58Check(payload); got != address {
+		t.Errorf("Base58Check(%x) = %s, want %s", payload, got, address)
+	}
+
+	got, err := Base58CheckDecode(address)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Errorf("Base58CheckDecode(%s) = %x, %v", address, got, err)
+	}
+
+	if _, err := Base58CheckDecode("16UwLL9Risc3QfPqBUvKofHmBQ7wMtjvN"); !errors.Is(err, ErrBase58Checksum) {
+		t.Errorf("Base58CheckDecode of a bad checksum returned %v, want ErrBase58Checksum", err)
+	}
+
+	if _, err := Base58CheckDecode("111"); !errors.Is(err, ErrBase58TooShort) {
+		t.Errorf("Base58CheckDecode of 3 zero bytes returned %v, want ErrBase58TooShort", err)
+	}
+}
@@ -0,0 +1,294 @@
+package btools
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// fieldElement is an integer modulo p = 2^256 - 2^32 - 977 stored as four
+// little endian 64 bit limbs. Elements are always kept fully reduced and
+// every operation runs in constant time.
+type fieldElement [4]uint64
+
+var fieldP = fieldElement{0xFFFFFFFEFFFFFC2F, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0xFFFFFFFFFFFFFFFF}
+
+// fieldC is 2^256 mod p.
+const fieldC = 0x1000003D1
+
+var fieldOne = fieldElement{1, 0, 0, 0}
+
+// fieldFromBytes reads a 32 bytes big endian integer, reducing it modulo p.
+func fieldFromBytes(b []byte) fieldElement {
+	var a fieldElement
+	for i := range 4 {
+		for j := range 8 {
+			a[3-i] = a[3-i]<<8 | uint64(b[i*8+j])
+		}
+	}
+
+	return a.reduce(0)
+}
+
+func fieldFromBig(n *big.Int) fieldElement {
+	if n.Sign() < 0 || n.BitLen() > 256 {
+		n = big.NewInt(0).Mod(n, p)
+	}
+
+	return fieldFromBytes(n.FillBytes(make([]byte, 32)))
+}
+
+func (a fieldElement) bytes() []byte {
+	b := make([]byte, 32)
+	for i := range 4 {
+		for j := range 8 {
+			b[i*8+j] = byte(a[3-i] >> (56 - 8*j))
+		}
+	}
+
+	return b
+}
+
+func (a fieldElement) big() *big.Int {
+	return big.NewInt(0).SetBytes(a.bytes())
+}
+
+// reduce returns (a + carry*2^256) mod p for a carry of 0 or 1 and any a
+// below 2^256.
+func (a fieldElement) reduce(carry uint64) fieldElement {
+	var t fieldElement
+	var borrow uint64
+	t[0], borrow = bits.Sub64(a[0], fieldP[0], 0)
+	t[1], borrow = bits.Sub64(a[1], fieldP[1], borrow)
+	t[2], borrow = bits.Sub64(a[2], fieldP[2], borrow)
+	t[3], borrow = bits.Sub64(a[3], fieldP[3], borrow)
+
+	// a - p wraps to a + 2^256 - p, which is the result both when a >= p
+	// and when there is a carry.
+	return a.choose(t, carry|(borrow^1))
+}
+
+// choose returns b if flag is 1 and a if it is 0.
+func (a fieldElement) choose(b fieldElement, flag uint64) fieldElement {
+	mask := -flag
+	return fieldElement{
+		a[0] ^ (mask & (a[0] ^ b[0])),
+		a[1] ^ (mask & (a[1] ^ b[1])),
+		a[2] ^ (mask & (a[2] ^ b[2])),
+		a[3] ^ (mask & (a[3] ^ b[3])),
+	}
+}
+
+// isZero returns 1 if a is zero and 0 otherwise.
+func (a fieldElement) isZero() uint64 {
+	v := a[0] | a[1] | a[2] | a[3]
+	return 1 ^ ((v | -v) >> 63)
+}
+
+// equal returns 1 if a and b are equal and 0 otherwise.
+func (a fieldElement) equal(b fieldElement) uint64 {
+	return fieldElement{a[0] ^ b[0], a[1] ^ b[1], a[2] ^ b[2], a[3] ^ b[3]}.isZero()
+}
+
+func (a fieldElement) isOdd() uint64 {
+	return a[0] & 1
+}
+
+func (a fieldElement) add(b fieldElement) fieldElement {
+	var r fieldElement
+	var carry uint64
+	r[0], carry = bits.Add64(a[0], b[0], 0)
+	r[1], carry = bits.Add64(a[1], b[1], carry)
+	r[2], carry = bits.Add64(a[2], b[2], carry)
+	r[3], carry = bits.Add64(a[3], b[3], carry)
+
+	return r.reduce(carry)
+}
+
+func (a fieldElement) sub(b fieldElement) fieldElement {
+	var r fieldElement
+	var borrow uint64
+	r[0], borrow = bits.Sub64(a[0], b[0], 0)
+	r[1], borrow = bits.Sub64(a[1], b[1], borrow)
+	r[2], borrow = bits.Sub64(a[2], b[2], borrow)
+	r[3], borrow = bits.Sub64(a[3], b[3], borrow)
+
+	// on borrow add p back
+	mask := -borrow
+	var carry uint64
+	r[0], carry = bits.Add64(r[0], fieldP[0]&mask, 0)
+	r[1], carry = bits.Add64(r[1], fieldP[1]&mask, carry)
+	r[2], carry = bits.Add64(r[2], fieldP[2]&mask, carry)
+	r[3], _ = bits.Add64(r[3], fieldP[3]&mask, carry)
+
+	return r
+}
+
+func (a fieldElement) neg() fieldElement {
+	return fieldElement{}.sub(a)
+}
+
+func (a fieldElement) double() fieldElement {
+	return a.add(a)
+}
+
+func (a fieldElement) mul(b fieldElement) fieldElement {
+	return fieldReduceWide(mul256(a, b))
+}
+
+// mul256 returns the 512 bit product of a and b.
+func mul256(a, b [4]uint64) [8]uint64 {
+	var t [8]uint64
+	var c uint64
+
+	c, t[0] = madd(a[0], b[0], 0, 0)
+	c, t[1] = madd(a[0], b[1], 0, c)
+	c, t[2] = madd(a[0], b[2], 0, c)
+	t[4], t[3] = madd(a[0], b[3], 0, c)
+
+	c, t[1] = madd(a[1], b[0], t[1], 0)
+	c, t[2] = madd(a[1], b[1], t[2], c)
+	c, t[3] = madd(a[1], b[2], t[3], c)
+	t[5], t[4] = madd(a[1], b[3], t[4], c)
+
+	c, t[2] = madd(a[2], b[0], t[2], 0)
+	c, t[3] = madd(a[2], b[1], t[3], c)
+	c, t[4] = madd(a[2], b[2], t[4], c)
+	t[6], t[5] = madd(a[2], b[3], t[5], c)
+
+	c, t[3] = madd(a[3], b[0], t[3], 0)
+	c, t[4] = madd(a[3], b[1], t[4], c)
+	c, t[5] = madd(a[3], b[2], t[5], c)
+	t[7], t[6] = madd(a[3], b[3], t[6], c)
+
+	return t
+}
+
+// madd returns x*y + z + carry as a 128 bit hi, lo pair, which cannot
+// overflow.
+func madd(x, y, z, carry uint64) (uint64, uint64) {
+	hi, lo := bits.Mul64(x, y)
+	var c uint64
+	lo, c = bits.Add64(lo, z, 0)
+	hi += c
+	lo, c = bits.Add64(lo, carry, 0)
+	hi += c
+
+	return hi, lo
+}
+
+func (a fieldElement) square() fieldElement {
+	var t [8]uint64
+	var c uint64
+
+	// cross products a[i]*a[j] with i < j
+	c, t[1] = madd(a[0], a[1], 0, 0)
+	c, t[2] = madd(a[0], a[2], 0, c)
+	t[4], t[3] = madd(a[0], a[3], 0, c)
+	c, t[3] = madd(a[1], a[2], t[3], 0)
+	t[5], t[4] = madd(a[1], a[3], t[4], c)
+	t[6], t[5] = madd(a[2], a[3], t[5], 0)
+
+	// doubled
+	t[7] = t[6] >> 63
+	t[6] = t[6]<<1 | t[5]>>63
+	t[5] = t[5]<<1 | t[4]>>63
+	t[4] = t[4]<<1 | t[3]>>63
+	t[3] = t[3]<<1 | t[2]>>63
+	t[2] = t[2]<<1 | t[1]>>63
+	t[1] = t[1] << 1
+
+	// plus the squares a[i]^2
+	h0, l0 := bits.Mul64(a[0], a[0])
+	h1, l1 := bits.Mul64(a[1], a[1])
+	h2, l2 := bits.Mul64(a[2], a[2])
+	h3, l3 := bits.Mul64(a[3], a[3])
+	t[0] = l0
+	t[1], c = bits.Add64(t[1], h0, 0)
+	t[2], c = bits.Add64(t[2], l1, c)
+	t[3], c = bits.Add64(t[3], h1, c)
+	t[4], c = bits.Add64(t[4], l2, c)
+	t[5], c = bits.Add64(t[5], h2, c)
+	t[6], c = bits.Add64(t[6], l3, c)
+	t[7], _ = bits.Add64(t[7], h3, c)
+
+	return fieldReduceWide(t)
+}
+
+// squareN squares a n times.
+func (a fieldElement) squareN(n int) fieldElement {
+	for range n {
+		a = a.square()
+	}
+
+	return a
+}
+
+// fieldReduceWide reduces a 512 bit product modulo p using
+// 2^256 = fieldC (mod p).
+func fieldReduceWide(t [8]uint64) fieldElement {
+	// r = t[0:4] + t[4:8]*fieldC, which fits in 5 limbs
+	var r [5]uint64
+	var carry uint64
+	carry, r[0] = madd(t[4], fieldC, t[0], 0)
+	carry, r[1] = madd(t[5], fieldC, t[1], carry)
+	carry, r[2] = madd(t[6], fieldC, t[2], carry)
+	r[4], r[3] = madd(t[7], fieldC, t[3], carry)
+
+	// fold r[4], below 2^34, the same way
+	hi, lo := bits.Mul64(r[4], fieldC)
+	var a fieldElement
+	var c uint64
+	a[0], c = bits.Add64(r[0], lo, 0)
+	a[1], c = bits.Add64(r[1], hi, c)
+	a[2], c = bits.Add64(r[2], 0, c)
+	a[3], c = bits.Add64(r[3], 0, c)
+
+	// a carry here leaves a small a, so adding fieldC cannot carry again
+	a[0], c = bits.Add64(a[0], fieldC&-c, 0)
+	a[1], c = bits.Add64(a[1], 0, c)
+	a[2], c = bits.Add64(a[2], 0, c)
+	a[3], _ = bits.Add64(a[3], 0, c)
+
+	return a.reduce(0)
+}
+
+// powChain returns a^(2^223 - 1) and the intermediate a^(2^2 - 1),
+// a^(2^3 - 1) and a^(2^22 - 1), from which the exponents used by inverse
+// and sqrt are built. The chain is the one used by libsecp256k1.
+func (a fieldElement) powChain() (x2, x3, x22, x223 fieldElement) {
+	x2 = a.square().mul(a)
+	x3 = x2.square().mul(a)
+	x6 := x3.squareN(3).mul(x3)
+	x9 := x6.squareN(3).mul(x3)
+	x11 := x9.squareN(2).mul(x2)
+	x22 = x11.squareN(11).mul(x11)
+	x44 := x22.squareN(22).mul(x22)
+	x88 := x44.squareN(44).mul(x44)
+	x176 := x88.squareN(88).mul(x88)
+	x220 := x176.squareN(44).mul(x44)
+	x223 = x220.squareN(3).mul(x3)
+
+	return x2, x3, x22, x223
+}
+
+// inverse returns a^-1 computed as a^(p-2), which maps zero to zero.
+func (a fieldElement) inverse() fieldElement {
+	x2, _, x22, x223 := a.powChain()
+
+	r := x223.squareN(23).mul(x22)
+	r = r.squareN(5).mul(a)
+	r = r.squareN(3).mul(x2)
+	return r.squareN(2).mul(a)
+}
+
+// sqrt returns a square root of a, computed as a^((p+1)/4), and 1 if a is a
+// square or 0 otherwise.
+func (a fieldElement) sqrt() (fieldElement, uint64) {
+	x2, _, x22, x223 := a.powChain()
+
+	r := x223.squareN(23).mul(x22)
+	r = r.squareN(6).mul(x2)
+	r = r.squareN(2)
+
+	return r, r.square().equal(a)
+}
@@ -0,0 +1,214 @@
+package btools
+
+import (
+	"encoding/hex"
+	"math/big"
+	"math/bits"
+)
+
+/*
+secp256k1 has an efficient endomorphism phi(x, y) = (beta*x, y) which equals
+multiplying by lambda, beta and lambda being cube roots of unity modulo p and
+n. Splitting k = k1 + k2*lambda with k1 and k2 of about 128 bits turns k*P
+into k1*P + k2*phi(P), halving the number of doublings (GLV method). The split
+and its constants are the ones used by libsecp256k1.
+*/
+
+var (
+	glvLambda  = scalarFromHex("5363ad4cc05c30e0a5261c028812645a122e22ea20816678df02967c1b23bd72")
+	glvBeta    = fieldFromBytes(mustDecodeHex("7ae96a2b657c07106e64479eac3434e99cf0497512f58995c1396c28719501ee"))
+	glvMinusB1 = scalarFromHex("00000000000000000000000000000000e4437ed6010e88286f547fa90abfe4c3")
+	glvMinusB2 = scalarFromHex("fffffffffffffffffffffffffffffffe8a280ac50774346dd765cda83db1562c")
+	glvG1      = scalarFromHex("3086d221a7d46bcde86c90e49284eb153daa8a1471e8ca7fe893209a45dbb031")
+	glvG2      = scalarFromHex("e4437ed6010e88286f547fa90abfe4c4221208ac9df506c61571b4ae8ac47f71")
+)
+
+func mustDecodeHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+func scalarFromHex(s string) scalar {
+	return scalarFromBytes(mustDecodeHex(s))
+}
+
+// splitLambda returns r1 and r2 with k = r1 + r2*lambda (mod n). Taken as
+// signed values, see isHigh, both are below 2^128 in absolute value.
+func (k scalar) splitLambda() (scalar, scalar) {
+	c1 := k.mulShift384(glvG1).mul(glvMinusB1)
+	c2 := k.mulShift384(glvG2).mul(glvMinusB2)
+
+	r2 := c1.add(c2)
+	r1 := r2.mul(glvLambda).neg().add(k)
+
+	return r1, r2
+}
+
+// mulShift384 returns round(a*b / 2^384).
+func (a scalar) mulShift384(b scalar) scalar {
+	t := mul256(a, b)
+
+	var r scalar
+	var c uint64
+	r[0], c = bits.Add64(t[6], t[5]>>63, 0)
+	r[1], _ = bits.Add64(t[7], 0, c)
+
+	return r
+}
+
+// isHigh returns 1 if a is above n/2, i.e. if it is the negative value a - n
+// when taken as signed, and 0 otherwise.
+func (a scalar) isHigh() uint64 {
+	halfN := scalar{0xDFE92F46681B20A0, 0x5D576E7357A4501D, 0xFFFFFFFFFFFFFFFF, 0x7FFFFFFFFFFFFFFF}
+
+	var borrow uint64
+	_, borrow = bits.Sub64(halfN[0], a[0], 0)
+	_, borrow = bits.Sub64(halfN[1], a[1], borrow)
+	_, borrow = bits.Sub64(halfN[2], a[2], borrow)
+	_, borrow = bits.Sub64(halfN[3], a[3], borrow)
+
+	return borrow
+}
+
+// abs returns the absolute value of a taken as signed, and 1 if a was
+// negative.
+func (a scalar) abs() (scalar, uint64) {
+	high := a.isHigh()
+	return a.choose(a.neg(), high), high
+}
+
+// endomorphism returns phi(p0) = lambda*p0.
+func (p0 jacobianPoint) endomorphism() jacobianPoint {
+	return jacobianPoint{x: p0.x.mul(glvBeta), y: p0.y, z: p0.z}
+}
+
+// mulGLV computes k*p0 in constant time with respect to k using the GLV
+// split and a fixed window of 4 bits over both halves.
+func (p0 jacobianPoint) mulGLV(k scalar) jacobianPoint {
+	k1, k2 := k.splitLambda()
+	k1, neg1 := k1.abs()
+	k2, neg2 := k2.abs()
+
+	var table, tablePhi [16]jacobianPoint
+	table[1] = p0
+	for i := 2; i < 16; i++ {
+		table[i] = table[i-1].add(p0)
+	}
+	for i := range table {
+		tablePhi[i] = table[i].endomorphism()
+	}
+
+	b1 := k1.bytes()[16:]
+	b2 := k2.bytes()[16:]
+
+	result := jacobianPoint{}
+	for i := range 32 {
+		shift := 4 * uint(1-i%2)
+		w1 := uint64(b1[i/2]>>shift) & 0x0F
+		w2 := uint64(b2[i/2]>>shift) & 0x0F
+
+		result = result.double().double().double().double()
+
+		q1 := lookupJacobian(&table, w1)
+		result = result.add(q1.choose(q1.neg(), neg1))
+
+		q2 := lookupJacobian(&tablePhi, w2)
+		result = result.add(q2.choose(q2.neg(), neg2))
+	}
+
+	return result
+}
+
+// mulSumVartime computes k1*P1 + k2*P2 + ... with Strauss' method: all the
+// products share the same doublings, and each one is split with GLV and
+// recoded in width 5 NAF. It is not constant time and must only be used
+// with public inputs, e.g. to verify signatures.
+func mulSumVartime(ks []scalar, ps []jacobianPoint) jacobianPoint {
+	const window = 5
+
+	type term struct {
+		naf   []int8
+		table [1 << (window - 2)]jacobianPoint
+	}
+
+	terms := make([]term, 0, 2*len(ks))
+	maxLen := 0
+	for i := range ks {
+		k1, k2 := ks[i].splitLambda()
+
+		var odd [1 << (window - 2)]jacobianPoint
+		odd[0] = ps[i]
+		double := ps[i].double()
+		for j := 1; j < len(odd); j++ {
+			odd[j] = odd[j-1].add(double)
+		}
+
+		for half, k := range []scalar{k1, k2} {
+			k, negative := k.abs()
+
+			var t term
+			t.naf = wNAF(k, window)
+			for j := range odd {
+				t.table[j] = odd[j]
+				if half == 1 {
+					t.table[j] = t.table[j].endomorphism()
+				}
+				if negative == 1 {
+					t.table[j] = t.table[j].neg()
+				}
+			}
+
+			if len(t.naf) > maxLen {
+				maxLen = len(t.naf)
+			}
+			terms = append(terms, t)
+		}
+	}
+
+	result := jacobianPoint{}
+	for i := maxLen - 1; i >= 0; i-- {
+		result = result.double()
+		for _, t := range terms {
+			if i >= len(t.naf) || t.naf[i] == 0 {
+				continue
+			}
+
+			d := t.naf[i]
+			if d > 0 {
+				result = result.add(t.table[d/2])
+			} else {
+				result = result.add(t.table[-d/2].neg())
+			}
+		}
+	}
+
+	return result
+}
+
+// wNAF returns the width w non-adjacent form of k, least significant digit
+// first. Non-zero digits are odd and below 2^(w-1) in absolute value.
+func wNAF(k scalar, w uint) []int8 {
+	n := big.NewInt(0).SetBytes(k.bytes())
+	mod := big.NewInt(1 << w)
+
+	naf := []int8{}
+	for n.Sign() > 0 {
+		var d int64
+		if n.Bit(0) == 1 {
+			d = big.NewInt(0).Mod(n, mod).Int64()
+			if d >= 1<<(w-1) {
+				d -= 1 << w
+			}
+			n.Sub(n, big.NewInt(d))
+		}
+
+		naf = append(naf, int8(d))
+		n.Rsh(n, 1)
+	}
+
+	return naf
+}
@@ -0,0 +1,264 @@
+package btools
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// naiveAdd adds two affine points with the textbook formulas, as a reference
+// independent of the field and Jacobian code.
+func naiveAdd(p0, p1 Point) Point {
+	if p0.IsInfinity() {
+		return p1
+	}
+	if p1.IsInfinity() {
+		return p0
+	}
+
+	var lambda *big.Int
+	if p0.X.Cmp(p1.X) == 0 {
+		if big.NewInt(0).Add(p0.Y, p1.Y).Mod(big.NewInt(0).Add(p0.Y, p1.Y), p).Sign() == 0 {
+			return Infinity()
+		}
+
+		// 3x^2 / 2y
+		num := big.NewInt(0).Mul(p0.X, p0.X)
+		num.Mul(num, big.NewInt(3))
+		den := big.NewInt(0).Lsh(p0.Y, 1)
+		lambda = num.Mul(num, den.ModInverse(den, p))
+	} else {
+		num := big.NewInt(0).Sub(p1.Y, p0.Y)
+		den := big.NewInt(0).Sub(p1.X, p0.X)
+		den.Mod(den, p)
+		lambda = num.Mul(num, den.ModInverse(den, p))
+	}
+	lambda.Mod(lambda, p)
+
+	x := big.NewInt(0).Mul(lambda, lambda)
+	x.Sub(x, p0.X).Sub(x, p1.X).Mod(x, p)
+
+	y := big.NewInt(0).Sub(p0.X, x)
+	y.Mul(y, lambda).Sub(y, p0.Y).Mod(y, p)
+
+	return Point{X: x, Y: y}
+}
+
+// naiveMul computes k*p0 by double-and-add.
+func naiveMul(k *big.Int, p0 Point) Point {
+	k = big.NewInt(0).Mod(k, secp256k1Order)
+
+	result := Infinity()
+	for i := k.BitLen() - 1; i >= 0; i-- {
+		result = naiveAdd(result, result)
+		if k.Bit(i) == 1 {
+			result = naiveAdd(result, p0)
+		}
+	}
+
+	return result
+}
+
+// edgeScalars returns the scalars the multiplications are tested with:
+// small values, values around n and powers of two, values around the GLV
+// lambda, and random ones.
+func edgeScalars(random int) []*big.Int {
+	n := secp256k1Order
+	lambda := glvLambda.big()
+	scalars := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(15),
+		big.NewInt(16),
+		big.NewInt(17),
+		big.NewInt(0).Sub(n, big.NewInt(1)),
+		big.NewInt(0).Sub(n, big.NewInt(2)),
+		n,
+		big.NewInt(0).Add(n, big.NewInt(1)),
+		big.NewInt(0).Rsh(n, 1),
+		big.NewInt(0).Add(big.NewInt(0).Rsh(n, 1), big.NewInt(1)),
+		big.NewInt(0).Lsh(big.NewInt(1), 128),
+		big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 128), big.NewInt(1)),
+		big.NewInt(0).Lsh(big.NewInt(1), 255),
+		big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+		lambda,
+		big.NewInt(0).Sub(n, lambda),
+		big.NewInt(0).Add(lambda, big.NewInt(1)),
+	}
+
+	for range random {
+		k, _ := rand.Int(rand.Reader, big.NewInt(0).Lsh(big.NewInt(1), 256))
+		scalars = append(scalars, k)
+	}
+
+	return scalars
+}
+
+func testPoints() []Point {
+	random, _ := rand.Int(rand.Reader, secp256k1Order)
+	return []Point{
+		g,
+		naiveMul(big.NewInt(7), g),
+		naiveMul(big.NewInt(0).Sub(secp256k1Order, big.NewInt(1)), g),
+		naiveMul(random, g),
+	}
+}
+
+func TestMulGenerator(t *testing.T) {
+	for _, k := range edgeScalars(100) {
+		got := mulGenerator(scalarFromBig(k)).point()
+		if want := naiveMul(k, g); !got.Equal(want) {
+			t.Errorf("mulGenerator(%x) = %v, want %v", k, got, want)
+		}
+	}
+}
+
+func TestMulGLV(t *testing.T) {
+	for _, pt := range testPoints() {
+		for _, k := range edgeScalars(25) {
+			got := jacobianFromPoint(pt).mulGLV(scalarFromBig(k)).point()
+			if want := naiveMul(k, pt); !got.Equal(want) {
+				t.Errorf("mulGLV(%x, %v) = %v, want %v", k, pt, got, want)
+			}
+		}
+
+		if got := jacobianFromPoint(Infinity()).mulGLV(scalarFromBig(big.NewInt(5))).point(); !got.IsInfinity() {
+			t.Errorf("mulGLV of infinity = %v", got)
+		}
+	}
+}
+
+func TestMulSumVartime(t *testing.T) {
+	points := testPoints()
+	scalars := edgeScalars(25)
+
+	for i, k := range scalars {
+		ks := []*big.Int{k, scalars[(i+1)%len(scalars)], scalars[(i+7)%len(scalars)]}
+
+		want := Infinity()
+		sks := []scalar{}
+		jps := []jacobianPoint{}
+		for j, kj := range ks {
+			pt := points[(i+j)%len(points)]
+			want = naiveAdd(want, naiveMul(kj, pt))
+			sks = append(sks, scalarFromBig(kj))
+			jps = append(jps, jacobianFromPoint(pt))
+		}
+
+		if got := mulSumVartime(sks, jps).point(); !got.Equal(want) {
+			t.Errorf("mulSumVartime(%x) = %v, want %v", ks, got, want)
+		}
+	}
+
+	// k*P + (n-k)*P is the point at infinity
+	k, _ := rand.Int(rand.Reader, secp256k1Order)
+	sum := mulSumVartime(
+		[]scalar{scalarFromBig(k), scalarFromBig(k).neg()},
+		[]jacobianPoint{jacobianFromPoint(points[3]), jacobianFromPoint(points[3])},
+	)
+	if !sum.point().IsInfinity() {
+		t.Errorf("k*P + (n-k)*P = %v, want infinity", sum.point())
+	}
+
+	if got := mulSumVartime(nil, nil).point(); !got.IsInfinity() {
+		t.Errorf("empty sum = %v, want infinity", got)
+	}
+}
+
+func TestSplitLambda(t *testing.T) {
+	bound := big.NewInt(0).Lsh(big.NewInt(1), 128)
+	lambda := glvLambda.big()
+
+	for _, k := range edgeScalars(1000) {
+		sk := scalarFromBig(k)
+		r1, r2 := sk.splitLambda()
+
+		sum := big.NewInt(0).Mul(r2.big(), lambda)
+		sum.Add(sum, r1.big()).Mod(sum, secp256k1Order)
+		if sum.Cmp(sk.big()) != 0 {
+			t.Errorf("splitLambda(%x): r1 + r2*lambda = %x", k, sum)
+		}
+
+		for _, r := range []scalar{r1, r2} {
+			abs, _ := r.abs()
+			if abs.big().Cmp(bound) >= 0 {
+				t.Errorf("splitLambda(%x): |%x| is not below 2^128", k, r.big())
+			}
+		}
+	}
+}
+
+func TestWNAF(t *testing.T) {
+	for _, k := range edgeScalars(100) {
+		sk := scalarFromBig(k)
+		for _, w := range []uint{2, 4, 5} {
+			naf := wNAF(sk, w)
+
+			value := big.NewInt(0)
+			for i := len(naf) - 1; i >= 0; i-- {
+				value.Lsh(value, 1).Add(value, big.NewInt(int64(naf[i])))
+
+				d := int(naf[i])
+				if d == 0 {
+					continue
+				}
+
+				if d%2 == 0 || d >= 1<<(w-1) || d <= -(1<<(w-1)) {
+					t.Fatalf("wNAF(%x, %d): digit %d out of range", k, w, d)
+				}
+
+				// at most one non-zero digit in any w consecutive ones
+				for j := i + 1; j < len(naf) && j < i+int(w); j++ {
+					if naf[j] != 0 {
+						t.Fatalf("wNAF(%x, %d): digits %d and %d are both non-zero", k, w, i, j)
+					}
+				}
+			}
+
+			if value.Cmp(sk.big()) != 0 {
+				t.Errorf("wNAF(%x, %d) = %x", k, w, value)
+			}
+		}
+	}
+}
+
+func BenchmarkMulGenerator(b *testing.B) {
+	k := scalarFromBig(benchmarkKey)
+	for range b.N {
+		mulGenerator(k)
+	}
+}
+
+func BenchmarkMulGLV(b *testing.B) {
+	k := scalarFromBig(benchmarkKey)
+	pt := jacobianFromPoint(Secp256k1Pub(big.NewInt(12345)))
+	for range b.N {
+		pt.mulGLV(k)
+	}
+}
+
+func BenchmarkMulSumVartime(b *testing.B) {
+	ks := []scalar{scalarFromBig(benchmarkKey), scalarFromBig(benchmarkKey).neg()}
+	ps := []jacobianPoint{jacobianFromPoint(g), jacobianFromPoint(Secp256k1Pub(big.NewInt(12345)))}
+	for range b.N {
+		mulSumVartime(ks, ps)
+	}
+}
+
+// BenchmarkAddressScan measures the cost per key of listing the addresses of
+// an account: one public derivation and one P2WPKH address.
+func BenchmarkAddressScan(b *testing.B) {
+	key := XPrivKey{PrivateKey: benchmarkKey, ChainCode: make([]byte, 32)}
+	chain := key.XPubKey()
+	for i := range b.N {
+		child, err := chain.CKDpub(uint32(i) % HardenedKeyStart)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := child.Address(AddressP2WPKH, Mainnet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,4 +2,7 @@ module github.com/artilugio0/btools
 
 go 1.23.4
 
-require golang.org/x/crypto v0.32.0 // indirect
+require (
+	golang.org/x/crypto v0.32.0
+	golang.org/x/text v0.21.0
+)
@@ -0,0 +1,124 @@
+package btools
+
+import (
+	"sync"
+)
+
+// affinePoint is a point with Z = 1, used for precomputed tables.
+type affinePoint struct {
+	x, y fieldElement
+}
+
+// gTable holds j * 16^i * G for every window i of a scalar and every
+// non-zero window value j, so that k*G is the sum of one entry per window
+// and needs no doubling.
+var (
+	gTable     *[64][16]affinePoint
+	gTableOnce sync.Once
+)
+
+func generatorTable() *[64][16]affinePoint {
+	gTableOnce.Do(func() {
+		points := make([]jacobianPoint, 0, 64*15)
+		base := jacobianFromPoint(g)
+		for range 64 {
+			acc := base
+			points = append(points, acc)
+			for j := 2; j < 16; j++ {
+				acc = acc.add(base)
+				points = append(points, acc)
+			}
+			base = base.double().double().double().double()
+		}
+
+		affine := batchToAffine(points)
+
+		gTable = &[64][16]affinePoint{}
+		for i := range 64 {
+			copy(gTable[i][1:], affine[i*15:(i+1)*15])
+		}
+	})
+
+	return gTable
+}
+
+// batchToAffine converts points, none of them the point at infinity, with a
+// single field inversion (Montgomery's trick).
+func batchToAffine(points []jacobianPoint) []affinePoint {
+	prefix := make([]fieldElement, len(points))
+	acc := fieldOne
+	for i, pt := range points {
+		prefix[i] = acc
+		acc = acc.mul(pt.z)
+	}
+
+	inv := acc.inverse()
+	result := make([]affinePoint, len(points))
+	for i := len(points) - 1; i >= 0; i-- {
+		zInv := inv.mul(prefix[i])
+		inv = inv.mul(points[i].z)
+
+		zInv2 := zInv.square()
+		result[i] = affinePoint{
+			x: points[i].x.mul(zInv2),
+			y: points[i].y.mul(zInv2).mul(zInv),
+		}
+	}
+
+	return result
+}
+
+// mulGenerator computes k*G in constant time with respect to k using the
+// precomputed table: one constant-time lookup and one mixed addition per
+// window of 4 bits.
+func mulGenerator(k scalar) jacobianPoint {
+	table := generatorTable()
+	b := k.bytes()
+
+	result := jacobianPoint{}
+	for i := range 64 {
+		// window i holds bits 4i to 4i+3
+		window := uint64(b[31-i/2]>>(4*uint(i%2))) & 0x0F
+
+		var entry affinePoint
+		for j := 1; j < 16; j++ {
+			diff := uint64(j) ^ window
+			equal := 1 ^ ((diff | -diff) >> 63)
+			entry.x = entry.x.choose(table[i][j].x, equal)
+			entry.y = entry.y.choose(table[i][j].y, equal)
+		}
+
+		diff := window
+		nonZero := (diff | -diff) >> 63
+		result = result.addAffine(entry, nonZero)
+	}
+
+	return result
+}
+
+// addAffine returns p0 + q if flag is 1 and p0 if it is 0, using the
+// madd-2007-bl formulas. It handles p0 being the point at infinity and
+// p0 = -q, but not p0 = q, which cannot happen while summing the windows of
+// mulGenerator: the sum of the lower windows is always smaller than the
+// next entry.
+func (p0 jacobianPoint) addAffine(q affinePoint, flag uint64) jacobianPoint {
+	z1z1 := p0.z.square()
+	u2 := q.x.mul(z1z1)
+	s2 := q.y.mul(p0.z).mul(z1z1)
+
+	h := u2.sub(p0.x)
+	hh := h.square()
+	i := hh.double().double()
+	j := h.mul(i)
+	r := s2.sub(p0.y).double()
+	v := p0.x.mul(i)
+
+	x3 := r.square().sub(j).sub(v.double())
+	y3 := r.mul(v.sub(x3)).sub(p0.y.mul(j).double())
+	z3 := p0.z.add(h).square().sub(z1z1).sub(hh)
+
+	result := jacobianPoint{x: x3, y: y3, z: z3}
+	result = result.choose(jacobianPoint{x: q.x, y: q.y, z: fieldOne}, p0.z.isZero())
+
+	return p0.choose(result, flag)
+}
@@ -0,0 +1,117 @@
+package btools
+
+/*
+Jacobian coordinates (X, Y, Z) represent the affine point (X/Z^2, Y/Z^3),
+Z = 0 is the point at infinity. They avoid a field inversion per addition,
+only one is needed to convert the final result back to affine coordinates.
+
+Every operation runs in constant time: the special cases of the addition
+formulas are computed anyway and selected with masks instead of branches.
+*/
+
+type jacobianPoint struct {
+	x, y, z fieldElement
+}
+
+func jacobianFromPoint(p0 Point) jacobianPoint {
+	if p0.infinity || p0.X == nil || p0.Y == nil {
+		return jacobianPoint{}
+	}
+
+	return jacobianPoint{x: fieldFromBig(p0.X), y: fieldFromBig(p0.Y), z: fieldOne}
+}
+
+// point converts back to affine coordinates.
+func (p0 jacobianPoint) point() Point {
+	if p0.z.isZero() == 1 {
+		return Infinity()
+	}
+
+	zInv := p0.z.inverse()
+	zInv2 := zInv.square()
+
+	x := p0.x.mul(zInv2)
+	y := p0.y.mul(zInv2).mul(zInv)
+
+	return Point{X: x.big(), Y: y.big()}
+}
+
+func (p0 jacobianPoint) choose(p1 jacobianPoint, flag uint64) jacobianPoint {
+	return jacobianPoint{
+		x: p0.x.choose(p1.x, flag),
+		y: p0.y.choose(p1.y, flag),
+		z: p0.z.choose(p1.z, flag),
+	}
+}
+
+func (p0 jacobianPoint) neg() jacobianPoint {
+	return jacobianPoint{x: p0.x, y: p0.y.neg(), z: p0.z}
+}
+
+// double uses the dbl-2009-l formulas for a = 0.
+func (p0 jacobianPoint) double() jacobianPoint {
+	a := p0.x.square()
+	b := p0.y.square()
+	c := b.square()
+
+	d := p0.x.add(b).square().sub(a).sub(c).double()
+	e := a.double().add(a)
+	f := e.square()
+
+	x3 := f.sub(d.double())
+	c8 := c.double().double().double()
+	y3 := e.mul(d.sub(x3)).sub(c8)
+	z3 := p0.y.mul(p0.z).double()
+
+	return jacobianPoint{x: x3, y: y3, z: z3}
+}
+
+// add uses the add-2007-bl formulas, selecting the doubling of p0 when both
+// points are equal and the other operand when one of them is the point at
+// infinity.
+func (p0 jacobianPoint) add(p1 jacobianPoint) jacobianPoint {
+	z1z1 := p0.z.square()
+	z2z2 := p1.z.square()
+
+	u1 := p0.x.mul(z2z2)
+	u2 := p1.x.mul(z1z1)
+	s1 := p0.y.mul(p1.z).mul(z2z2)
+	s2 := p1.y.mul(p0.z).mul(z1z1)
+
+	h := u2.sub(u1)
+	i := h.double().square()
+	j := h.mul(i)
+	r := s2.sub(s1).double()
+	v := u1.mul(i)
+
+	x3 := r.square().sub(j).sub(v.double())
+	y3 := r.mul(v.sub(x3)).sub(s1.mul(j).double())
+
+	// when h is zero z3 is zero too, which is the right result for
+	// p0 = -p1 and is replaced by the doubling below for p0 = p1
+	z3 := p0.z.add(p1.z).square().sub(z1z1).sub(z2z2).mul(h)
+
+	result := jacobianPoint{x: x3, y: y3, z: z3}
+
+	inf0 := p0.z.isZero()
+	inf1 := p1.z.isZero()
+	same := h.isZero() & r.isZero() & (inf0 ^ 1) & (inf1 ^ 1)
+
+	result = result.choose(p0.double(), same)
+	result = result.choose(p1, inf0)
+	result = result.choose(p0, inf1)
+
+	return result
+}
+
+// lookupJacobian returns table[index] reading every entry of the table.
+func lookupJacobian(table *[16]jacobianPoint, index uint64) jacobianPoint {
+	var result jacobianPoint
+	for i := range table {
+		diff := uint64(i) ^ index
+		equal := 1 ^ ((diff | -diff) >> 63)
+		result = result.choose(table[i], equal)
+	}
+
+	return result
+}
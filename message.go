@@ -0,0 +1,129 @@
+package btools
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+/*
+Bitcoin signed messages (BIP137) are compact recoverable ECDSA signatures of
+the double SHA-256 of a prefixed message, encoded in base64. The header byte
+carries the recovery id and the type of the address:
+
+	27-30  P2PKH, uncompressed public key
+	31-34  P2PKH, compressed public key
+	35-38  P2SH-P2WPKH
+	39-42  P2WPKH
+
+Electrum signs every address type with the P2PKH compressed header, so on
+verification that header is accepted for SegWit addresses too.
+*/
+
+const signedMessagePrefix = "Bitcoin Signed Message:\n"
+
+// SignedMessageHash returns the hash signed by SignMessage for msg.
+func SignedMessageHash(msg string) []byte {
+	data := appendVarInt(nil, uint64(len(signedMessagePrefix)))
+	data = append(data, signedMessagePrefix...)
+	data = appendVarInt(data, uint64(len(msg)))
+	data = append(data, msg...)
+
+	return doubleSHA256(data)
+}
+
+// SignMessage signs msg with the private key priv for its address of type t,
+// returning the base64 encoded signature.
+func SignMessage(priv *big.Int, t AddressType, msg string) (string, error) {
+	var base byte
+	switch t {
+	case AddressP2PKH:
+		base = 31
+	case AddressP2SHP2WPKH:
+		base = 35
+	case AddressP2WPKH:
+		base = 39
+	default:
+		return "", fmt.Errorf("signed messages are not supported for %s addresses", t)
+	}
+
+	sig, err := SignCompact(priv, SignedMessageHash(msg), true)
+	if err != nil {
+		return "", err
+	}
+
+	sig[0] = base + (sig[0]-27)&3
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifyMessage checks the base64 encoded signature sig of msg for address
+// on any of the registered networks.
+func VerifyMessage(address, msg, sig string) (bool, error) {
+	data, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return false, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+	}
+
+	if len(data) != 65 {
+		return false, fmt.Errorf("%w: invalid length %d", ErrInvalidSignature, len(data))
+	}
+
+	header := data[0]
+	if header < 27 || header > 42 {
+		return false, fmt.Errorf("%w: invalid header %d", ErrInvalidSignature, header)
+	}
+
+	s, err := ParseCompactSignature(data[1:])
+	if err != nil {
+		return false, err
+	}
+
+	pub, err := RecoverPublicKey(SignedMessageHash(msg), s, (header-27)&3)
+	if err != nil {
+		return false, nil
+	}
+
+	var types []AddressType
+	switch {
+	case header < 31:
+		return verifyUncompressedP2PKH(pub, address), nil
+	case header < 35:
+		types = []AddressType{AddressP2PKH, AddressP2SHP2WPKH, AddressP2WPKH}
+	case header < 39:
+		types = []AddressType{AddressP2SHP2WPKH}
+	default:
+		types = []AddressType{AddressP2WPKH}
+	}
+
+	for _, net := range Networks {
+		for _, t := range types {
+			a, err := Address(pub, t, net)
+			if err != nil {
+				return false, err
+			}
+
+			if a == address {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func verifyUncompressedP2PKH(pub Point, address string) bool {
+	uncompressed, err := Secp256k1Uncompressed(pub)
+	if err != nil {
+		return false
+	}
+
+	keyHash := hash160(uncompressed)
+	for _, net := range Networks {
+		if Base58Check(append([]byte{net.P2PKHVersion}, keyHash...)) == address {
+			return true
+		}
+	}
+
+	return false
+}
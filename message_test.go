@@ -0,0 +1,129 @@
+package btools
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+// the key of the signed message example of bitcoinjs-message and its
+// addresses
+const (
+	messageWIF          = "L4rK1yDtCWekvXuE6oXD9jCYfFNV2cWRpVuPLBcCU2z8TrisoyY1"
+	messageUncompressed = "1HZwkjkeaoZfTSaJxDw6aKkxp45agDiEzN"
+	messageP2PKH        = "1F3sAm6ZtwLAUnj7d38pGFxtP3RVEvtsbV"
+	messageP2SHP2WPKH   = "3DnW8JGpPViEZdpqat8qky1zc26EKbXnmM"
+	messageP2WPKH       = "bc1qngw83fg8dz0k749cg7k3emc7v98wy0c74dlrkd"
+)
+
+// signatures of the message key made with btcd, one per header range for
+// recovery ids 0 and 1
+var messageVectors = []struct {
+	msg     string
+	address string
+	t       AddressType
+	sig     string
+}{
+	{"message 0", messageUncompressed, AddressP2PKH, "G+w35kAa0BtHL/LEmxhmj9xZKML3lzEBdr5lYD2NKz1aE8l9bNkP3GgGXB0kJb6rZfIMhvxHnnGT/RZu/YLRydM="},
+	{"message 0", messageP2PKH, AddressP2PKH, "H+w35kAa0BtHL/LEmxhmj9xZKML3lzEBdr5lYD2NKz1aE8l9bNkP3GgGXB0kJb6rZfIMhvxHnnGT/RZu/YLRydM="},
+	{"message 0", messageP2SHP2WPKH, AddressP2SHP2WPKH, "I+w35kAa0BtHL/LEmxhmj9xZKML3lzEBdr5lYD2NKz1aE8l9bNkP3GgGXB0kJb6rZfIMhvxHnnGT/RZu/YLRydM="},
+	{"message 0", messageP2WPKH, AddressP2WPKH, "J+w35kAa0BtHL/LEmxhmj9xZKML3lzEBdr5lYD2NKz1aE8l9bNkP3GgGXB0kJb6rZfIMhvxHnnGT/RZu/YLRydM="},
+	{"message 1", messageUncompressed, AddressP2PKH, "HPQoUfgq1KF5ZzEPOv1o+pmMr2J+7crjlrPXMV4IPUMIEyqqdnFKYUp6BJo07dSkk330l6kVNIJ+g2jTxdc+R+w="},
+	{"message 1", messageP2PKH, AddressP2PKH, "IPQoUfgq1KF5ZzEPOv1o+pmMr2J+7crjlrPXMV4IPUMIEyqqdnFKYUp6BJo07dSkk330l6kVNIJ+g2jTxdc+R+w="},
+	{"message 1", messageP2SHP2WPKH, AddressP2SHP2WPKH, "JPQoUfgq1KF5ZzEPOv1o+pmMr2J+7crjlrPXMV4IPUMIEyqqdnFKYUp6BJo07dSkk330l6kVNIJ+g2jTxdc+R+w="},
+	{"message 1", messageP2WPKH, AddressP2WPKH, "KPQoUfgq1KF5ZzEPOv1o+pmMr2J+7crjlrPXMV4IPUMIEyqqdnFKYUp6BJo07dSkk330l6kVNIJ+g2jTxdc+R+w="},
+	{"This is an example of a signed message.", messageP2PKH, AddressP2PKH, "H9L5yLFjti0QTHhPyFrZCT1V/MMnBtXKmoiKDZ78NDBjERki6ZTQZdSMCtkgoNmp17By9ItJr8o7ChX0XxY91nk="},
+}
+
+func TestMessage(t *testing.T) {
+	wif, err := DecodeWIF(messageWIF)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range messageVectors {
+		if ok, err := VerifyMessage(v.address, v.msg, v.sig); !ok || err != nil {
+			t.Errorf("VerifyMessage(%s, %q, %s) = %v, %v", v.address, v.msg, v.sig, ok, err)
+		}
+
+		if ok, err := VerifyMessage(v.address, v.msg+".", v.sig); ok || err != nil {
+			t.Errorf("VerifyMessage(%s) of a tampered message = %v, %v", v.address, ok, err)
+		}
+
+		// only the uncompressed P2PKH address is not signed with SignMessage
+		if v.address == messageUncompressed {
+			continue
+		}
+
+		if sig, err := SignMessage(wif.PrivateKey, v.t, v.msg); err != nil || sig != v.sig {
+			t.Errorf("SignMessage(%s, %q) = %s, %v, want %s", v.t, v.msg, sig, err, v.sig)
+		}
+	}
+
+	// Electrum signs SegWit addresses with the P2PKH header
+	for _, address := range []string{messageP2SHP2WPKH, messageP2WPKH} {
+		if ok, err := VerifyMessage(address, "message 0", messageVectors[1].sig); !ok || err != nil {
+			t.Errorf("VerifyMessage(%s) with a P2PKH header = %v, %v", address, ok, err)
+		}
+	}
+
+	// the other ranges commit to a single address type
+	wrongAddress := []struct {
+		address string
+		sig     string
+	}{
+		{messageP2PKH, messageVectors[0].sig},
+		{messageUncompressed, messageVectors[1].sig},
+		{messageP2PKH, messageVectors[2].sig},
+		{messageP2WPKH, messageVectors[2].sig},
+		{messageP2PKH, messageVectors[3].sig},
+		{messageP2SHP2WPKH, messageVectors[3].sig},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", messageVectors[1].sig},
+	}
+
+	for _, tt := range wrongAddress {
+		if ok, err := VerifyMessage(tt.address, "message 0", tt.sig); ok || err != nil {
+			t.Errorf("VerifyMessage(%s, %s) = %v, %v", tt.address, tt.sig, ok, err)
+		}
+	}
+
+	// testnet addresses of the same key verify too
+	testnet, err := Address(Secp256k1Pub(wif.PrivateKey), AddressP2WPKH, Testnet3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := VerifyMessage(testnet, "message 0", messageVectors[3].sig); !ok || err != nil {
+		t.Errorf("VerifyMessage(%s) = %v, %v", testnet, ok, err)
+	}
+
+	if _, err := SignMessage(wif.PrivateKey, AddressP2TR, "message 0"); err == nil {
+		t.Errorf("SignMessage accepted a p2tr address")
+	}
+}
+
+func TestMessageInvalidSignature(t *testing.T) {
+	sig, _ := base64.StdEncoding.DecodeString(messageVectors[0].sig)
+
+	for _, header := range []byte{0, 26, 43, 255} {
+		data := append([]byte{header}, sig[1:]...)
+		_, err := VerifyMessage(messageP2PKH, "message 0", base64.StdEncoding.EncodeToString(data))
+		if !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifyMessage with header %d returned %v, want ErrInvalidSignature", header, err)
+		}
+	}
+
+	for _, s := range []string{"not base64", base64.StdEncoding.EncodeToString(sig[:64])} {
+		if _, err := VerifyMessage(messageP2PKH, "message 0", s); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("VerifyMessage(%q) returned %v, want ErrInvalidSignature", s, err)
+		}
+	}
+
+	// recovery ids 2 and 3 need r + n to be below p, not the case here
+	for _, header := range []byte{29, 30, 33, 34} {
+		data := append([]byte{header}, sig[1:]...)
+		if ok, _ := VerifyMessage(messageP2PKH, "message 0", base64.StdEncoding.EncodeToString(data)); ok {
+			t.Errorf("VerifyMessage with header %d verified", header)
+		}
+	}
+}
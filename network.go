@@ -0,0 +1,173 @@
+package btools
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Network holds the parameters that change between Bitcoin networks, or
+// between Bitcoin and other coins using the same key and address formats.
+type Network struct {
+	Name string
+
+	// KeyVersions is the version set used to serialize BIP32 extended keys.
+	KeyVersions KeyVersions
+
+	// PurposeKeyVersions maps BIP44 style purposes to the SLIP-132 version
+	// sets used by their accounts.
+	PurposeKeyVersions map[uint32]KeyVersions
+
+	WIFPrefix    byte
+	P2PKHVersion byte
+	P2SHVersion  byte
+	Bech32HRP    string
+
+	// CoinType is the BIP44 coin type of the network.
+	CoinType uint32
+}
+
+var (
+	Mainnet = Network{
+		Name:        "mainnet",
+		KeyVersions: XPubVersions,
+		PurposeKeyVersions: map[uint32]KeyVersions{
+			44: XPubVersions,
+			49: YPubVersions,
+			84: ZPubVersions,
+			86: XPubVersions,
+		},
+		WIFPrefix:    0x80,
+		P2PKHVersion: 0x00,
+		P2SHVersion:  0x05,
+		Bech32HRP:    "bc",
+		CoinType:     0,
+	}
+
+	Testnet3 = testNetwork("testnet3", "tb")
+	Testnet4 = testNetwork("testnet4", "tb")
+	Signet   = testNetwork("signet", "tb")
+	Regtest  = testNetwork("regtest", "bcrt")
+)
+
+func testNetwork(name, hrp string) Network {
+	return Network{
+		Name:        name,
+		KeyVersions: TPubVersions,
+		PurposeKeyVersions: map[uint32]KeyVersions{
+			44: TPubVersions,
+			49: UPubVersions,
+			84: VPubVersions,
+			86: TPubVersions,
+		},
+		WIFPrefix:    0xEF,
+		P2PKHVersion: 0x6F,
+		P2SHVersion:  0xC4,
+		Bech32HRP:    hrp,
+		CoinType:     1,
+	}
+}
+
+var ErrUnknownNetwork = errors.New("unknown network")
+
+var networks = map[string]Network{}
+
+// Networks lists the registered networks in registration order, starting
+// with the built-in ones.
+var Networks []Network
+
+func init() {
+	for _, net := range []Network{Mainnet, Testnet3, Testnet4, Signet, Regtest} {
+		if err := RegisterNetwork(net); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterNetwork makes net available to GetNetwork and its extended key
+// versions known to ParseExtendedKey.
+func RegisterNetwork(net Network) error {
+	if net.Name == "" {
+		return fmt.Errorf("network name is empty")
+	}
+
+	if _, ok := networks[net.Name]; ok {
+		return fmt.Errorf("network %q is already registered", net.Name)
+	}
+
+	if len(net.KeyVersions.Private) != 4 || len(net.KeyVersions.Public) != 4 {
+		return fmt.Errorf("network %q: invalid extended key versions", net.Name)
+	}
+
+	if err := validateBech32HRP(net.Bech32HRP); err != nil {
+		return fmt.Errorf("network %q: %w", net.Name, err)
+	}
+
+	// sorted so that the first conflict reported does not depend on the
+	// iteration order of the map
+	purposes := slices.Sorted(maps.Keys(net.PurposeKeyVersions))
+
+	versionSets := []KeyVersions{net.KeyVersions}
+	for _, p := range purposes {
+		versionSets = append(versionSets, net.PurposeKeyVersions[p])
+	}
+
+	// every set is checked before any is registered, so that a network that
+	// is rejected leaves KeyVersionSets untouched
+	added := []KeyVersions{}
+	for _, v := range versionSets {
+		if len(v.Private) != 4 || len(v.Public) != 4 {
+			return fmt.Errorf("network %q: invalid extended key versions %q", net.Name, v.Name)
+		}
+
+		known, _, ok := lookupKeyVersions(v.Public)
+		if !ok {
+			i := slices.IndexFunc(added, func(a KeyVersions) bool { return bytes.Equal(a.Public, v.Public) })
+			if i < 0 {
+				added = append(added, v)
+				continue
+			}
+			known = added[i]
+		}
+
+		if !bytes.Equal(known.Private, v.Private) {
+			return fmt.Errorf("network %q: extended key versions %q conflict with %q", net.Name, v.Name, known.Name)
+		}
+	}
+
+	KeyVersionSets = append(KeyVersionSets, added...)
+	networks[net.Name] = net
+	Networks = append(Networks, net)
+
+	return nil
+}
+
+func GetNetwork(name string) (Network, error) {
+	net, ok := networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("%w: %s", ErrUnknownNetwork, name)
+	}
+
+	return net, nil
+}
+
+// NetworkForKeyVersions returns the first registered network that uses
+// versions, either for BIP32 keys or for the accounts of a purpose. The test
+// networks share their versions, so a tpub gives testnet3.
+func NetworkForKeyVersions(versions KeyVersions) (Network, error) {
+	for _, net := range Networks {
+		if bytes.Equal(net.KeyVersions.Public, versions.Public) {
+			return net, nil
+		}
+
+		for _, v := range net.PurposeKeyVersions {
+			if bytes.Equal(v.Public, versions.Public) {
+				return net, nil
+			}
+		}
+	}
+
+	return Network{}, fmt.Errorf("%w: no network uses %s keys", ErrUnknownNetwork, versions.Name)
+}
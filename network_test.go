@@ -0,0 +1,194 @@
+package btools
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// restoreNetworks undoes the registrations made by a test.
+func restoreNetworks(t *testing.T) {
+	names := map[string]bool{}
+	for _, net := range Networks {
+		names[net.Name] = true
+	}
+	registered := slices.Clone(Networks)
+	versionSets := slices.Clone(KeyVersionSets)
+
+	t.Cleanup(func() {
+		for name := range networks {
+			if !names[name] {
+				delete(networks, name)
+			}
+		}
+		Networks = registered
+		KeyVersionSets = versionSets
+	})
+}
+
+func litecoinNetwork() Network {
+	ltub := KeyVersions{"Ltub", []byte{0x01, 0x9D, 0x9C, 0xFE}, []byte{0x01, 0x9D, 0xA4, 0x62}}
+	mtub := KeyVersions{"Mtub", []byte{0x01, 0xB2, 0x6E, 0xF6}, []byte{0x01, 0xB2, 0x67, 0x92}}
+
+	return Network{
+		Name:        "ltc",
+		KeyVersions: ltub,
+		PurposeKeyVersions: map[uint32]KeyVersions{
+			44: ltub,
+			49: mtub,
+			84: ZPubVersions,
+		},
+		WIFPrefix:    0xB0,
+		P2PKHVersion: 0x30,
+		P2SHVersion:  0x32,
+		Bech32HRP:    "ltc",
+		CoinType:     2,
+	}
+}
+
+func TestRegisterNetwork(t *testing.T) {
+	restoreNetworks(t)
+
+	sets := len(KeyVersionSets)
+	if err := RegisterNetwork(litecoinNetwork()); err != nil {
+		t.Fatal(err)
+	}
+
+	net, err := GetNetwork("ltc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if net.Bech32HRP != "ltc" || Networks[len(Networks)-1].Name != "ltc" {
+		t.Errorf("registered network %+v", net)
+	}
+
+	// Ltub and Mtub are new, zpub was already known
+	if len(KeyVersionSets) != sets+2 {
+		t.Errorf("%d version sets registered, want 2", len(KeyVersionSets)-sets)
+	}
+
+	if _, err := GetKeyVersions("Mtub"); err != nil {
+		t.Error(err)
+	}
+
+	if err := RegisterNetwork(litecoinNetwork()); err == nil {
+		t.Errorf("registered ltc twice")
+	}
+}
+
+func TestNetworkForKeyVersions(t *testing.T) {
+	restoreNetworks(t)
+
+	if err := RegisterNetwork(litecoinNetwork()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		versions KeyVersions
+		network  string
+	}{
+		{XPubVersions, "mainnet"},
+		{ZPubVersions, "mainnet"},
+		{TPubVersions, "testnet3"},
+		{VPubVersions, "testnet3"},
+		{litecoinNetwork().KeyVersions, "ltc"},
+		{litecoinNetwork().PurposeKeyVersions[49], "ltc"},
+	}
+
+	for _, tt := range tests {
+		if net, err := NetworkForKeyVersions(tt.versions); err != nil || net.Name != tt.network {
+			t.Errorf("NetworkForKeyVersions(%s) = %s, %v, want %s", tt.versions.Name, net.Name, err, tt.network)
+		}
+	}
+
+	// multisig versions are not used by any single signature account
+	if _, err := NetworkForKeyVersions(ZPubMultisigVersions); !errors.Is(err, ErrUnknownNetwork) {
+		t.Errorf("NetworkForKeyVersions(Zpub) returned %v, want ErrUnknownNetwork", err)
+	}
+}
+
+func TestRegisterNetworkRejected(t *testing.T) {
+	restoreNetworks(t)
+
+	conflicting := XPubVersions
+	conflicting.Name = "Xpub"
+	conflicting.Private = []byte{0x01, 0x02, 0x03, 0x04}
+
+	sameNewPublic := litecoinNetwork().KeyVersions
+	sameNewPublic.Private = []byte{0x01, 0x02, 0x03, 0x04}
+
+	tests := []struct {
+		name   string
+		modify func(net *Network)
+		err    string
+	}{
+		{
+			name:   "empty name",
+			modify: func(net *Network) { net.Name = "" },
+			err:    "network name is empty",
+		},
+		{
+			name:   "registered name",
+			modify: func(net *Network) { net.Name = Mainnet.Name },
+			err:    `network "mainnet" is already registered`,
+		},
+		{
+			name:   "invalid hrp",
+			modify: func(net *Network) { net.Bech32HRP = "" },
+		},
+		{
+			name:   "short versions",
+			modify: func(net *Network) { net.PurposeKeyVersions[86] = KeyVersions{"bad", []byte{1}, []byte{2}} },
+			err:    `network "ltc": invalid extended key versions "bad"`,
+		},
+		{
+			name: "conflict with a known set",
+			modify: func(net *Network) {
+				net.PurposeKeyVersions[86] = conflicting
+				net.PurposeKeyVersions[87] = conflicting
+			},
+			err: `network "ltc": extended key versions "Xpub" conflict with "xpub"`,
+		},
+		{
+			name:   "conflict between new sets",
+			modify: func(net *Network) { net.PurposeKeyVersions[86] = sameNewPublic },
+			err:    `network "ltc": extended key versions "Ltub" conflict with "Ltub"`,
+		},
+	}
+
+	for _, tt := range tests {
+		sets := slices.Clone(KeyVersionSets)
+		registered := len(Networks)
+
+		net := litecoinNetwork()
+		tt.modify(&net)
+
+		// the same error every time, whatever the order of the purposes
+		for range 10 {
+			err := RegisterNetwork(net)
+			if err == nil {
+				t.Fatalf("%s: registered", tt.name)
+			}
+
+			if tt.err != "" && err.Error() != tt.err {
+				t.Errorf("%s: error %q, want %q", tt.name, err, tt.err)
+				break
+			}
+		}
+
+		if !slices.EqualFunc(KeyVersionSets, sets, func(a, b KeyVersions) bool { return a.Name == b.Name }) {
+			t.Errorf("%s: rejected network changed the version sets", tt.name)
+		}
+
+		if len(Networks) != registered {
+			t.Errorf("%s: rejected network was registered", tt.name)
+		}
+
+		if net.Name != Mainnet.Name {
+			if _, err := GetNetwork(net.Name); err == nil {
+				t.Errorf("%s: GetNetwork(%q) succeeded", tt.name, net.Name)
+			}
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package btools
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HardenedKeyStart is the first index of the hardened child keys.
+const HardenedKeyStart uint32 = 1 << 31
+
+var ErrHardenedPublicDerivation = errors.New("cannot derive a hardened child from a public key")
+
+// DerivationPath is a list of child indexes, hardened indexes include
+// HardenedKeyStart.
+type DerivationPath []uint32
+
+// ParseDerivationPath parses paths like m/84'/0'/0'/0/5 or m/48h/1h/0h/2h.
+// The leading m is optional.
+func ParseDerivationPath(s string) (DerivationPath, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, "/")
+	if parts[0] == "m" || parts[0] == "M" {
+		parts = parts[1:]
+	}
+
+	path := DerivationPath{}
+	for _, part := range parts {
+		hardened := false
+		if strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H") {
+			hardened = true
+			part = part[:len(part)-1]
+		}
+
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q: invalid index %q", s, part)
+		}
+
+		if uint32(i) >= HardenedKeyStart {
+			return nil, fmt.Errorf("invalid derivation path %q: index %d is out of range", s, i)
+		}
+
+		if hardened {
+			i += uint64(HardenedKeyStart)
+		}
+
+		path = append(path, uint32(i))
+	}
+
+	return path, nil
+}
+
+func (path DerivationPath) String() string {
+	builder := strings.Builder{}
+	builder.WriteString("m")
+	for _, i := range path {
+		builder.WriteString("/")
+		if i >= HardenedKeyStart {
+			builder.WriteString(strconv.FormatUint(uint64(i-HardenedKeyStart), 10))
+			builder.WriteString("'")
+		} else {
+			builder.WriteString(strconv.FormatUint(uint64(i), 10))
+		}
+	}
+
+	return builder.String()
+}
+
+// Child returns a new path with the given indexes appended.
+func (path DerivationPath) Child(indexes ...uint32) DerivationPath {
+	child := append(DerivationPath{}, path...)
+	return append(child, indexes...)
+}
+
+func (xpriv XPrivKey) DerivePath(path DerivationPath) (XPrivKey, error) {
+	key := xpriv
+	for n, i := range path {
+		var err error
+		key, err = key.CKDpriv(i)
+		if err != nil {
+			return XPrivKey{}, fmt.Errorf("deriving %s: %w", path[:n+1], err)
+		}
+	}
+
+	return key, nil
+}
+
+func (xpub XPubKey) DerivePath(path DerivationPath) (XPubKey, error) {
+	key := xpub
+	for n, i := range path {
+		if i >= HardenedKeyStart {
+			return XPubKey{}, fmt.Errorf("deriving %s: %w", path[:n+1], ErrHardenedPublicDerivation)
+		}
+
+		var err error
+		key, err = key.CKDpub(i)
+		if err != nil {
+			return XPubKey{}, fmt.Errorf("deriving %s: %w", path[:n+1], err)
+		}
+	}
+
+	return key, nil
+}
@@ -0,0 +1,421 @@
+package btools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// UnknownWord marks a position of the mnemonic whose word could not be read.
+const UnknownWord = "?"
+
+const recoveryChunkSize = 4096
+
+type RecoveryOptions struct {
+	// Language of the mnemonic, English if empty.
+	Language Language
+
+	// Words is the mnemonic as read from the backup. Positions holding
+	// UnknownWord or an empty string are tried with every word of the list.
+	Words []string
+
+	// MaxEditDistance is the maximum number of edits (insertions, deletions,
+	// substitutions or transpositions of letters) used to find candidates for
+	// words that are not in the wordlist.
+	MaxEditDistance int
+
+	// AllowSwaps also tries every transposition of two adjacent words.
+	AllowSwaps bool
+
+	Passphrase string
+
+	// Match is called for every candidate with a valid checksum. When set, the
+	// search stops at the first matching candidate. Matching requires deriving
+	// the seed, which is much slower than checking the checksum.
+	Match RecoveryMatcher
+
+	// Workers is the number of goroutines used for the search, the number of
+	// CPUs if 0.
+	Workers int
+
+	// Start resumes a search from a checkpoint reported through Progress.
+	Start uint64
+
+	Progress func(RecoveryProgress)
+}
+
+type RecoveryProgress struct {
+	Checked uint64
+	Total   uint64
+	Found   int
+
+	// Checkpoint is the position below which every candidate has been
+	// checked. Use it as RecoveryOptions.Start to resume the search.
+	Checkpoint uint64
+}
+
+type RecoveryMatcher func(seed *Seed) (bool, error)
+
+// MatchFingerprint matches the fingerprint of the master key.
+func MatchFingerprint(fingerprint []byte) RecoveryMatcher {
+	return func(seed *Seed) (bool, error) {
+		master, err := MasterPrivateKey(seed)
+		if err != nil {
+			return false, err
+		}
+
+		masterFingerprint, err := master.Fingerprint()
+		if err != nil {
+			return false, err
+		}
+
+		return bytes.Equal(masterFingerprint, fingerprint), nil
+	}
+}
+
+// MatchXPub matches the extended public key found at path. xpub may use the
+// versions of any registered network or SLIP-132 set: only its public key
+// and chain code are compared.
+func MatchXPub(xpub string, path DerivationPath) RecoveryMatcher {
+	target, _, err := ParseExtendedKey(xpub)
+	if err == nil {
+		if _, ok := target.(XPubKey); !ok {
+			err = fmt.Errorf("%s is not an extended public key", xpub)
+		}
+	}
+
+	return func(seed *Seed) (bool, error) {
+		if err != nil {
+			return false, err
+		}
+
+		master, err := MasterPrivateKey(seed)
+		if err != nil {
+			return false, err
+		}
+
+		key, err := master.DerivePath(path)
+		if err != nil {
+			return false, err
+		}
+
+		pub := key.XPubKey()
+		want := target.(XPubKey)
+
+		return pub.PublicKey.Equal(want.PublicKey) && bytes.Equal(pub.ChainCode, want.ChainCode), nil
+	}
+}
+
+// MatchAddress matches the address of type t on net of the key found at
+// path, usually the first receive address of the wallet.
+func MatchAddress(address string, path DerivationPath, t AddressType, net Network) RecoveryMatcher {
+	return func(seed *Seed) (bool, error) {
+		master, err := MasterPrivateKey(seed)
+		if err != nil {
+			return false, err
+		}
+
+		key, err := master.DerivePath(path)
+		if err != nil {
+			return false, err
+		}
+
+		a, err := key.XPubKey().Address(t, net)
+		if err != nil {
+			return false, err
+		}
+
+		return a == address, nil
+	}
+}
+
+// recoverySpace enumerates the candidates of a search. Each variant is a
+// list of candidate word indexes per position; candidates are numbered
+// variant after variant in mixed radix.
+type recoverySpace struct {
+	variants [][][]int
+	sizes    []uint64
+	total    uint64
+}
+
+func (s *recoverySpace) candidate(n uint64, indexes []int) {
+	v := 0
+	for n >= s.sizes[v] {
+		n -= s.sizes[v]
+		v++
+	}
+
+	positions := s.variants[v]
+	for i := len(positions) - 1; i >= 0; i-- {
+		l := uint64(len(positions[i]))
+		indexes[i] = positions[i][n%l]
+		n /= l
+	}
+}
+
+func newRecoverySpace(wl *Wordlist, opts RecoveryOptions) (*recoverySpace, error) {
+	l := len(opts.Words)
+	if l != 12 && l != 15 && l != 18 && l != 21 && l != 24 {
+		return nil, fmt.Errorf("%w: %d words", ErrInvalidWordCount, l)
+	}
+
+	positions := make([][]int, l)
+	for i, w := range opts.Words {
+		if w == "" || w == UnknownWord {
+			positions[i] = make([]int, len(wl.words))
+			for j := range wl.words {
+				positions[i][j] = j
+			}
+			continue
+		}
+
+		if index, ok := wl.Index(w); ok {
+			positions[i] = []int{index}
+			continue
+		}
+
+		w = norm.NFKD.String(w)
+		for j, candidate := range wl.words {
+			if editDistance(w, candidate) <= opts.MaxEditDistance {
+				positions[i] = append(positions[i], j)
+			}
+		}
+
+		if len(positions[i]) == 0 {
+			return nil, UnknownWordError{Position: i, Word: w}
+		}
+	}
+
+	variants := [][][]int{positions}
+	if opts.AllowSwaps {
+		for i := range l - 1 {
+			if len(positions[i]) == 1 && len(positions[i+1]) == 1 &&
+				positions[i][0] == positions[i+1][0] {
+				continue
+			}
+
+			swapped := append([][]int{}, positions...)
+			swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+			variants = append(variants, swapped)
+		}
+	}
+
+	s := &recoverySpace{variants: variants}
+	for _, v := range variants {
+		size := uint64(1)
+		for _, p := range v {
+			if size > math.MaxUint64/uint64(len(p)) {
+				return nil, fmt.Errorf("search space is too large")
+			}
+			size *= uint64(len(p))
+		}
+
+		if s.total > math.MaxUint64-size {
+			return nil, fmt.Errorf("search space is too large")
+		}
+
+		s.sizes = append(s.sizes, size)
+		s.total += size
+	}
+
+	return s, nil
+}
+
+// RecoverMnemonic searches for the mnemonics compatible with a damaged backup
+// that have a valid checksum and, if opts.Match is set, match it.
+func RecoverMnemonic(ctx context.Context, opts RecoveryOptions) ([][]string, error) {
+	lang := opts.Language
+	if lang == "" {
+		lang = English
+	}
+
+	wl, err := GetWordlist(lang)
+	if err != nil {
+		return nil, err
+	}
+
+	space, err := newRecoverySpace(wl, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Start > space.total {
+		return nil, fmt.Errorf("start %d is beyond the search space of %d candidates", opts.Start, space.total)
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type chunkResult struct {
+		start     uint64
+		end       uint64
+		mnemonics [][]string
+		err       error
+	}
+
+	chunks := make(chan uint64)
+	results := make(chan chunkResult)
+
+	go func() {
+		defer close(chunks)
+		for start := opts.Start; start < space.total; start += recoveryChunkSize {
+			select {
+			case chunks <- start:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			indexes := make([]int, len(opts.Words))
+			for start := range chunks {
+				end := min(start+recoveryChunkSize, space.total)
+				r := chunkResult{start: start, end: end}
+
+				for n := start; n < end && r.err == nil; n++ {
+					space.candidate(n, indexes)
+					if _, ok := entropyFromIndexes(indexes); !ok {
+						continue
+					}
+
+					mnemonic := make([]string, len(indexes))
+					for i, index := range indexes {
+						mnemonic[i] = wl.Word(index)
+					}
+
+					if opts.Match == nil {
+						r.mnemonics = append(r.mnemonics, mnemonic)
+						continue
+					}
+
+					seed, err := NewSeed(mnemonic, opts.Passphrase)
+					if err != nil {
+						r.err = err
+						break
+					}
+
+					ok, err := opts.Match(seed)
+					if err != nil {
+						r.err = err
+						break
+					}
+
+					if ok {
+						r.mnemonics = append(r.mnemonics, mnemonic)
+					}
+				}
+
+				select {
+				case results <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	found := [][]string{}
+	seen := map[string]bool{}
+	done := map[uint64]uint64{}
+	progress := RecoveryProgress{
+		Checked:    opts.Start,
+		Total:      space.total,
+		Checkpoint: opts.Start,
+	}
+
+	var searchErr error
+	for r := range results {
+		if r.err != nil {
+			searchErr = r.err
+			cancel()
+			continue
+		}
+
+		for _, m := range r.mnemonics {
+			key := strings.Join(m, " ")
+			if !seen[key] {
+				seen[key] = true
+				found = append(found, m)
+			}
+		}
+
+		progress.Checked += r.end - r.start
+		progress.Found = len(found)
+
+		done[r.start] = r.end
+		for end, ok := done[progress.Checkpoint]; ok; end, ok = done[progress.Checkpoint] {
+			delete(done, progress.Checkpoint)
+			progress.Checkpoint = end
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+
+		if opts.Match != nil && len(found) > 0 {
+			cancel()
+		}
+	}
+
+	if searchErr != nil {
+		return found, searchErr
+	}
+
+	if opts.Match != nil && len(found) > 0 {
+		return found, nil
+	}
+
+	return found, ctx.Err()
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, where transposing two adjacent letters counts as a single edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
@@ -0,0 +1,264 @@
+package btools
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// BIP84 test wallet: abandon (x11) about, no passphrase
+const (
+	recoveryMnemonic    = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	recoveryFingerprint = "73c5da0a"
+	recoveryXPub        = "xpub6CatWdiZiodmUeTDp8LT5or8nmbKNcuyvz7WyksVFkKB4RHwCD3XyuvPEbvqAQY3rAPshWcMLoP2fMFMKHPJ4ZeZXYVUhLv1VMrjPC7PW6V"
+	recoveryZPub        = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+	recoveryAddress     = "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu"
+)
+
+var (
+	recoveryAccountPath = DerivationPath{84 + HardenedKeyStart, HardenedKeyStart, HardenedKeyStart}
+	recoveryAddressPath = recoveryAccountPath.Child(0, 0)
+)
+
+func TestMatchers(t *testing.T) {
+	seed, err := NewSeed(strings.Fields(recoveryMnemonic), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint, _ := hex.DecodeString(recoveryFingerprint)
+
+	tests := []struct {
+		name    string
+		matcher RecoveryMatcher
+		want    bool
+	}{
+		{"fingerprint", MatchFingerprint(fingerprint), true},
+		{"wrong fingerprint", MatchFingerprint([]byte{0, 0, 0, 0}), false},
+		{"xpub", MatchXPub(recoveryXPub, recoveryAccountPath), true},
+		{"xpub at other path", MatchXPub(recoveryXPub, recoveryAccountPath.Child(0)), false},
+		{"zpub", MatchXPub(recoveryZPub, recoveryAccountPath), true},
+		{"zpub at other path", MatchXPub(recoveryZPub, recoveryAccountPath.Child(1)), false},
+		{"address", MatchAddress(recoveryAddress, recoveryAddressPath, AddressP2WPKH, Mainnet), true},
+		{"address of other index", MatchAddress(recoveryAddress, recoveryAccountPath.Child(0, 1), AddressP2WPKH, Mainnet), false},
+		{"address of other type", MatchAddress(recoveryAddress, recoveryAddressPath, AddressP2TR, Mainnet), false},
+		{"address of other network", MatchAddress(recoveryAddress, recoveryAddressPath, AddressP2WPKH, Testnet3), false},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.matcher(seed)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	master, err := MasterPrivateKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	xprv, err := master.SerializeKey(Mainnet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, xpub := range []string{"zpub", xprv} {
+		if _, err := MatchXPub(xpub, recoveryAccountPath)(seed); err == nil {
+			t.Errorf("MatchXPub(%s) accepted an invalid key", xpub)
+		}
+	}
+}
+
+func TestRecoverMnemonic(t *testing.T) {
+	fingerprint, _ := hex.DecodeString(recoveryFingerprint)
+
+	tests := []struct {
+		name  string
+		words string
+		opts  RecoveryOptions
+	}{
+		{
+			name:  "unknown word",
+			words: "abandon abandon abandon ? abandon abandon abandon abandon abandon abandon abandon about",
+			opts:  RecoveryOptions{Match: MatchFingerprint(fingerprint)},
+		},
+		{
+			name:  "misspelled word",
+			words: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abuot",
+			opts:  RecoveryOptions{MaxEditDistance: 2, Match: MatchXPub(recoveryXPub, recoveryAccountPath)},
+		},
+		{
+			name:  "swapped words",
+			words: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about abandon",
+			opts:  RecoveryOptions{AllowSwaps: true, Match: MatchAddress(recoveryAddress, recoveryAddressPath, AddressP2WPKH, Mainnet)},
+		},
+		{
+			name:  "swapped and misspelled words",
+			words: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abuot abandon",
+			opts:  RecoveryOptions{AllowSwaps: true, MaxEditDistance: 2, Match: MatchFingerprint(fingerprint)},
+		},
+	}
+
+	for _, tt := range tests {
+		opts := tt.opts
+		opts.Words = strings.Fields(tt.words)
+
+		found, err := RecoverMnemonic(context.Background(), opts)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		if len(found) != 1 || strings.Join(found[0], " ") != recoveryMnemonic {
+			t.Errorf("%s: found %v, want [%s]", tt.name, found, recoveryMnemonic)
+		}
+	}
+}
+
+func TestRecoverMnemonicCandidates(t *testing.T) {
+	found, err := RecoverMnemonic(context.Background(), RecoveryOptions{
+		Words:           strings.Fields("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abuot"),
+		MaxEditDistance: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every candidate is within the edit distance and has a valid checksum
+	words := []string{}
+	for _, m := range found {
+		last := m[len(m)-1]
+		if d := editDistance("abuot", last); d > 2 {
+			t.Errorf("candidate %q is at distance %d", last, d)
+		}
+
+		if _, err := MnemonicToEntropy(m); err != nil {
+			t.Errorf("candidate %q: %v", last, err)
+		}
+
+		words = append(words, last)
+	}
+
+	if !slices.Contains(words, "about") {
+		t.Errorf("candidates %v do not include about", words)
+	}
+}
+
+func TestRecoverMnemonicResume(t *testing.T) {
+	// 2048 words for the unknown position in the original order and in each
+	// of the 11 swaps, several chunks in total
+	opts := RecoveryOptions{
+		Words:      strings.Fields("abandon abandon abandon abandon abandon ability able about above absent absorb ?"),
+		AllowSwaps: true,
+		Workers:    1,
+	}
+
+	all, err := RecoverMnemonic(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var checkpoint, total uint64
+	interrupted := opts
+	interrupted.Progress = func(p RecoveryProgress) {
+		if checkpoint == 0 {
+			checkpoint, total = p.Checkpoint, p.Total
+			cancel()
+		}
+	}
+
+	partial, err := RecoverMnemonic(ctx, interrupted)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("interrupted search returned %v, want context.Canceled", err)
+	}
+
+	if checkpoint == 0 || checkpoint >= total {
+		t.Fatalf("checkpoint %d of %d does not interrupt the search", checkpoint, total)
+	}
+
+	resumed := opts
+	resumed.Start = checkpoint
+	rest, err := RecoverMnemonic(context.Background(), resumed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{}
+	for _, m := range all {
+		want[strings.Join(m, " ")] = true
+	}
+
+	got := map[string]bool{}
+	for _, m := range append(partial, rest...) {
+		got[strings.Join(m, " ")] = true
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("resumed search found %d mnemonics, want %d", len(got), len(want))
+	}
+
+	for m := range want {
+		if !got[m] {
+			t.Errorf("resumed search missed %q", m)
+		}
+	}
+
+	if _, err := RecoverMnemonic(context.Background(), RecoveryOptions{Words: opts.Words, Start: total + 1}); err == nil {
+		t.Errorf("start beyond the search space was accepted")
+	}
+}
+
+func TestRecoverMnemonicStopsAtMatch(t *testing.T) {
+	fingerprint, _ := hex.DecodeString(recoveryFingerprint)
+
+	var last RecoveryProgress
+	found, err := RecoverMnemonic(context.Background(), RecoveryOptions{
+		Words:    strings.Fields("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon ? ?"),
+		Match:    MatchFingerprint(fingerprint),
+		Workers:  1,
+		Progress: func(p RecoveryProgress) { last = p },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(found) != 1 || strings.Join(found[0], " ") != recoveryMnemonic {
+		t.Errorf("found %v, want [%s]", found, recoveryMnemonic)
+	}
+
+	if last.Checked >= last.Total {
+		t.Errorf("checked %d of %d candidates, want the search to stop at the match", last.Checked, last.Total)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"about", "about", 0},
+		{"abuot", "about", 1},
+		{"abut", "about", 1},
+		{"abouts", "about", 1},
+		{"abaut", "about", 1},
+		{"ca", "abc", 3},
+		{"", "abc", 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package btools
+
+import (
+	"math/big"
+	"math/bits"
+)
+
+// scalar is an integer modulo the order n of secp256k1 stored as four little
+// endian 64 bit limbs. Scalars are always kept fully reduced and every
+// operation runs in constant time.
+type scalar [4]uint64
+
+var scalarN = scalar{0xBFD25E8CD0364141, 0xBAAEDCE6AF48A03B, 0xFFFFFFFFFFFFFFFE, 0xFFFFFFFFFFFFFFFF}
+
+// scalarNC is 2^256 - n.
+var scalarNC = [3]uint64{0x402DA1732FC9BEBF, 0x4551231950B75FC4, 0x1}
+
+// scalarFromBytes reads a 32 bytes big endian integer, reducing it modulo n.
+func scalarFromBytes(b []byte) scalar {
+	return scalarFromBytesUnreduced(b).reduce(0)
+}
+
+// scalarFromCanonicalBytes reads a 32 bytes big endian integer and returns 1
+// if it is in [1, n-1], or 0 if it had to be reduced or is zero.
+func scalarFromCanonicalBytes(b []byte) (scalar, uint64) {
+	a := scalarFromBytesUnreduced(b)
+	r := a.reduce(0)
+
+	diff := scalar{a[0] ^ r[0], a[1] ^ r[1], a[2] ^ r[2], a[3] ^ r[3]}
+	return r, diff.isZero() & (1 ^ r.isZero())
+}
+
+func scalarFromBytesUnreduced(b []byte) scalar {
+	var a scalar
+	for i := range 4 {
+		for j := range 8 {
+			a[3-i] = a[3-i]<<8 | uint64(b[i*8+j])
+		}
+	}
+
+	return a
+}
+
+func scalarFromBig(k *big.Int) scalar {
+	if k.Sign() < 0 || k.BitLen() > 256 {
+		k = big.NewInt(0).Mod(k, secp256k1Order)
+	}
+
+	return scalarFromBytes(k.FillBytes(make([]byte, 32)))
+}
+
+func (a scalar) bytes() []byte {
+	b := make([]byte, 32)
+	for i := range 4 {
+		for j := range 8 {
+			b[i*8+j] = byte(a[3-i] >> (56 - 8*j))
+		}
+	}
+
+	return b
+}
+
+func (a scalar) big() *big.Int {
+	return big.NewInt(0).SetBytes(a.bytes())
+}
+
+// reduce returns (a + carry*2^256) mod n for a carry of 0 or 1 and any a
+// below 2^256.
+func (a scalar) reduce(carry uint64) scalar {
+	var t scalar
+	var borrow uint64
+	t[0], borrow = bits.Sub64(a[0], scalarN[0], 0)
+	t[1], borrow = bits.Sub64(a[1], scalarN[1], borrow)
+	t[2], borrow = bits.Sub64(a[2], scalarN[2], borrow)
+	t[3], borrow = bits.Sub64(a[3], scalarN[3], borrow)
+
+	return a.choose(t, carry|(borrow^1))
+}
+
+// choose returns b if flag is 1 and a if it is 0.
+func (a scalar) choose(b scalar, flag uint64) scalar {
+	mask := -flag
+	return scalar{
+		a[0] ^ (mask & (a[0] ^ b[0])),
+		a[1] ^ (mask & (a[1] ^ b[1])),
+		a[2] ^ (mask & (a[2] ^ b[2])),
+		a[3] ^ (mask & (a[3] ^ b[3])),
+	}
+}
+
+// isZero returns 1 if a is zero and 0 otherwise.
+func (a scalar) isZero() uint64 {
+	v := a[0] | a[1] | a[2] | a[3]
+	return 1 ^ ((v | -v) >> 63)
+}
+
+func (a scalar) add(b scalar) scalar {
+	var r scalar
+	var carry uint64
+	r[0], carry = bits.Add64(a[0], b[0], 0)
+	r[1], carry = bits.Add64(a[1], b[1], carry)
+	r[2], carry = bits.Add64(a[2], b[2], carry)
+	r[3], carry = bits.Add64(a[3], b[3], carry)
+
+	return r.reduce(carry)
+}
+
+func (a scalar) neg() scalar {
+	var r scalar
+	var borrow uint64
+	r[0], borrow = bits.Sub64(scalarN[0], a[0], 0)
+	r[1], borrow = bits.Sub64(scalarN[1], a[1], borrow)
+	r[2], borrow = bits.Sub64(scalarN[2], a[2], borrow)
+	r[3], _ = bits.Sub64(scalarN[3], a[3], borrow)
+
+	// n - 0 must be 0
+	return r.choose(scalar{}, a.isZero())
+}
+
+func (a scalar) mul(b scalar) scalar {
+	return scalarReduceWide(mul256(a, b))
+}
+
+// inverse returns a^-1 computed as a^(n-2), which maps zero to zero. The
+// exponent is public, so the sequence of operations does not depend on a.
+func (a scalar) inverse() scalar {
+	e := scalarN
+	e[0] -= 2
+
+	r := scalar{1, 0, 0, 0}
+	for i := 255; i >= 0; i-- {
+		r = r.mul(r)
+		if e[i/64]>>(i%64)&1 == 1 {
+			r = r.mul(a)
+		}
+	}
+
+	return r
+}
+
+// scalarReduceWide reduces a 512 bit integer modulo n by repeatedly
+// replacing hi*2^256 with hi*(2^256 - n). Four rounds always bring the
+// value below 2^256.
+func scalarReduceWide(t [8]uint64) scalar {
+	for range 4 {
+		t = scalarFold(t)
+	}
+
+	return scalar{t[0], t[1], t[2], t[3]}.reduce(0)
+}
+
+// scalarFold returns t[0:4] + t[4:8]*scalarNC.
+func scalarFold(t [8]uint64) [8]uint64 {
+	var r [8]uint64
+	copy(r[:4], t[:4])
+
+	for i := range 4 {
+		var carry uint64
+		for j := range 3 {
+			hi, lo := bits.Mul64(t[4+i], scalarNC[j])
+			var c uint64
+			lo, c = bits.Add64(lo, r[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			r[i+j] = lo
+			carry = hi
+		}
+
+		for k := i + 3; k < 8; k++ {
+			r[k], carry = bits.Add64(r[k], carry, 0)
+		}
+	}
+
+	return r
+}
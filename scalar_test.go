@@ -0,0 +1,87 @@
+package btools
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+func TestScalarArithmetic(t *testing.T) {
+	n := secp256k1Order
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(1),
+		big.NewInt(2),
+		big.NewInt(0).Sub(n, big.NewInt(1)),
+		big.NewInt(0).Rsh(n, 1),
+		big.NewInt(0).Lsh(big.NewInt(1), 128),
+	}
+	for range 50 {
+		v, _ := rand.Int(rand.Reader, n)
+		values = append(values, v)
+	}
+
+	for _, a := range values {
+		sa := scalarFromBig(a)
+		if sa.big().Cmp(a) != 0 {
+			t.Fatalf("scalarFromBig(%x).big() = %x", a, sa.big())
+		}
+
+		want := big.NewInt(0).Neg(a)
+		if got := sa.neg().big(); got.Cmp(want.Mod(want, n)) != 0 {
+			t.Errorf("-%x = %x, want %x", a, got, want)
+		}
+
+		want = big.NewInt(0).ModInverse(a, n)
+		if want == nil {
+			want = big.NewInt(0)
+		}
+		if got := sa.inverse().big(); got.Cmp(want) != 0 {
+			t.Errorf("%x^-1 = %x, want %x", a, got, want)
+		}
+
+		wantHigh := a.Cmp(big.NewInt(0).Rsh(n, 1)) > 0
+		if got := sa.isHigh() == 1; got != wantHigh {
+			t.Errorf("isHigh(%x) = %v, want %v", a, got, wantHigh)
+		}
+
+		for _, b := range values[:10] {
+			sb := scalarFromBig(b)
+
+			want := big.NewInt(0).Add(a, b)
+			if got := sa.add(sb).big(); got.Cmp(want.Mod(want, n)) != 0 {
+				t.Errorf("%x + %x = %x, want %x", a, b, got, want)
+			}
+
+			want = big.NewInt(0).Mul(a, b)
+			if got := sa.mul(sb).big(); got.Cmp(want.Mod(want, n)) != 0 {
+				t.Errorf("%x * %x = %x, want %x", a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestScalarFromCanonicalBytes(t *testing.T) {
+	tests := []struct {
+		value *big.Int
+		ok    uint64
+	}{
+		{big.NewInt(0), 0},
+		{big.NewInt(1), 1},
+		{big.NewInt(0).Sub(secp256k1Order, big.NewInt(1)), 1},
+		{secp256k1Order, 0},
+		{big.NewInt(0).Add(secp256k1Order, big.NewInt(1)), 0},
+		{big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 256), big.NewInt(1)), 0},
+	}
+
+	for _, tt := range tests {
+		k, ok := scalarFromCanonicalBytes(tt.value.FillBytes(make([]byte, 32)))
+		if ok != tt.ok {
+			t.Errorf("scalarFromCanonicalBytes(%x) ok = %d, want %d", tt.value, ok, tt.ok)
+		}
+
+		if ok == 1 && k.big().Cmp(tt.value) != 0 {
+			t.Errorf("scalarFromCanonicalBytes(%x) = %x", tt.value, k.big())
+		}
+	}
+}
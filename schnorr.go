@@ -0,0 +1,220 @@
+package btools
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+)
+
+// XOnlyPubKey is a BIP340 public key: the x coordinate of a point whose y is
+// implicitly even.
+type XOnlyPubKey [32]byte
+
+// NewXOnlyPubKey returns the x-only public key of pub, dropping its y.
+func NewXOnlyPubKey(pub Point) (XOnlyPubKey, error) {
+	if pub.IsInfinity() {
+		return XOnlyPubKey{}, ErrPointAtInfinity
+	}
+
+	if !pub.IsOnCurve() {
+		return XOnlyPubKey{}, fmt.Errorf("invalid public key: point is not on the curve")
+	}
+
+	var xonly XOnlyPubKey
+	pub.X.FillBytes(xonly[:])
+
+	return xonly, nil
+}
+
+// ParseXOnlyPubKey decodes a 32 bytes x-only public key, checking that it
+// is the x coordinate of a point of the curve.
+func ParseXOnlyPubKey(data []byte) (XOnlyPubKey, error) {
+	if len(data) != 32 {
+		return XOnlyPubKey{}, fmt.Errorf("invalid x-only public key length: %d", len(data))
+	}
+
+	var xonly XOnlyPubKey
+	copy(xonly[:], data)
+	if _, err := xonly.Point(); err != nil {
+		return XOnlyPubKey{}, err
+	}
+
+	return xonly, nil
+}
+
+// Point returns the point with x coordinate xonly and an even y, the lift_x
+// function of BIP340.
+func (xonly XOnlyPubKey) Point() (Point, error) {
+	return decompressPoint(append([]byte{0x02}, xonly[:]...))
+}
+
+// TaggedHash computes SHA256(SHA256(tag) || SHA256(tag) || msg) as defined
+// in BIP340.
+func TaggedHash(tag string, msg ...[]byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, m := range msg {
+		h.Write(m)
+	}
+
+	return h.Sum(nil)
+}
+
+// SchnorrSign signs msg with the private key priv as specified in BIP340.
+// auxRand must be 32 bytes of fresh randomness; nil reads them from
+// crypto/rand.
+func SchnorrSign(priv *big.Int, msg []byte, auxRand []byte) ([]byte, error) {
+	if priv == nil || priv.Sign() <= 0 || priv.Cmp(secp256k1Order) >= 0 {
+		return nil, fmt.Errorf("invalid private key: out of range")
+	}
+
+	if auxRand == nil {
+		auxRand = make([]byte, 32)
+		if _, err := rand.Read(auxRand); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(auxRand) != 32 {
+		return nil, fmt.Errorf("invalid auxiliary randomness length: %d", len(auxRand))
+	}
+
+	// the key and the nonce are only handled as scalars, which run in
+	// constant time
+	d := scalarFromBig(priv)
+	P := mulGenerator(d).point()
+	d = d.choose(d.neg(), uint64(P.Y.Bit(0)))
+
+	pubBytes := P.X.FillBytes(make([]byte, 32))
+
+	t := xorBytes(d.bytes(), TaggedHash("BIP0340/aux", auxRand))
+	k := scalarFromBytes(TaggedHash("BIP0340/nonce", t, pubBytes, msg))
+	if k.isZero() == 1 {
+		return nil, fmt.Errorf("invalid nonce")
+	}
+
+	R := mulGenerator(k).point()
+	k = k.choose(k.neg(), uint64(R.Y.Bit(0)))
+
+	rBytes := R.X.FillBytes(make([]byte, 32))
+	e := scalarFromBig(schnorrChallenge(rBytes, pubBytes, msg))
+
+	s := e.mul(d).add(k)
+
+	sig := append(rBytes, s.bytes()...)
+	if !SchnorrVerify(XOnlyPubKey(pubBytes), msg, sig) {
+		return nil, fmt.Errorf("produced signature does not verify")
+	}
+
+	return sig, nil
+}
+
+// SchnorrVerify checks the BIP340 signature sig of msg for pub.
+func SchnorrVerify(pub XOnlyPubKey, msg []byte, sig []byte) bool {
+	P, r, s, ok := parseSchnorrInput(pub, sig)
+	if !ok {
+		return false
+	}
+
+	e := schnorrChallenge(sig[:32], pub[:], msg)
+	e.Sub(secp256k1Order, e)
+
+	// R = s*G - e*P
+	R := mulSumVartime(
+		[]scalar{scalarFromBig(s), scalarFromBig(e)},
+		[]jacobianPoint{jacobianFromPoint(g), jacobianFromPoint(P)},
+	).point()
+	if R.IsInfinity() || R.Y.Bit(0) == 1 {
+		return false
+	}
+
+	return R.X.Cmp(r) == 0
+}
+
+// SchnorrBatchVerify checks several BIP340 signatures at once. It returns
+// true only if every signature is valid, but does not tell which one failed.
+func SchnorrBatchVerify(pubs []XOnlyPubKey, msgs [][]byte, sigs [][]byte) (bool, error) {
+	if len(pubs) != len(msgs) || len(pubs) != len(sigs) {
+		return false, fmt.Errorf("batch sizes do not match: %d public keys, %d messages and %d signatures", len(pubs), len(msgs), len(sigs))
+	}
+
+	// (s1 + a2*s2 + ... + au*su)*G - R1 - a2*R2 - ... - au*Ru - e1*P1 - (a2*e2)*P2 - ... - (au*eu)*Pu
+	// must be the point at infinity
+	lhs := scalar{}
+	scalars := []scalar{}
+	points := []jacobianPoint{}
+	for i := range pubs {
+		P, _, s, ok := parseSchnorrInput(pubs[i], sigs[i])
+		if !ok {
+			return false, nil
+		}
+
+		R, err := XOnlyPubKey(sigs[i][:32]).Point()
+		if err != nil {
+			return false, nil
+		}
+
+		a := scalar{1, 0, 0, 0}
+		if i > 0 {
+			random, err := randomScalar()
+			if err != nil {
+				return false, err
+			}
+			a = scalarFromBig(random)
+		}
+
+		e := scalarFromBig(schnorrChallenge(sigs[i][:32], pubs[i][:], msgs[i]))
+
+		lhs = lhs.add(a.mul(scalarFromBig(s)))
+		scalars = append(scalars, a.neg(), a.mul(e).neg())
+		points = append(points, jacobianFromPoint(R), jacobianFromPoint(P))
+	}
+
+	scalars = append(scalars, lhs)
+	points = append(points, jacobianFromPoint(g))
+
+	return mulSumVartime(scalars, points).z.isZero() == 1, nil
+}
+
+func parseSchnorrInput(pub XOnlyPubKey, sig []byte) (Point, *big.Int, *big.Int, bool) {
+	if len(sig) != 64 {
+		return Point{}, nil, nil, false
+	}
+
+	P, err := pub.Point()
+	if err != nil {
+		return Point{}, nil, nil, false
+	}
+
+	r := big.NewInt(0).SetBytes(sig[:32])
+	if r.Cmp(p) >= 0 {
+		return Point{}, nil, nil, false
+	}
+
+	s := big.NewInt(0).SetBytes(sig[32:])
+	if s.Cmp(secp256k1Order) >= 0 {
+		return Point{}, nil, nil, false
+	}
+
+	return P, r, s, true
+}
+
+func schnorrChallenge(r, pub, msg []byte) *big.Int {
+	e := big.NewInt(0).SetBytes(TaggedHash("BIP0340/challenge", r, pub, msg))
+	return e.Mod(e, secp256k1Order)
+}
+
+// randomScalar returns a uniformly random integer in [1, n-1].
+func randomScalar() (*big.Int, error) {
+	max := big.NewInt(0).Sub(secp256k1Order, big.NewInt(1))
+	a, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Add(a, big.NewInt(1)), nil
+}
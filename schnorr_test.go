@@ -0,0 +1,138 @@
+package btools
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"testing"
+)
+
+type bip340Vector struct {
+	index   string
+	priv    []byte
+	pub     XOnlyPubKey
+	auxRand []byte
+	msg     []byte
+	sig     []byte
+	valid   bool
+	comment string
+}
+
+// testdata/bip340_vectors.csv is test-vectors.csv of BIP340.
+func readBIP340Vectors(t *testing.T) []bip340Vector {
+	f, err := os.Open("testdata/bip340_vectors.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vectors := []bip340Vector{}
+	for _, r := range records[1:] {
+		v := bip340Vector{index: r[0], valid: r[6] == "TRUE", comment: r[7]}
+		v.priv, _ = hex.DecodeString(r[1])
+		pub, _ := hex.DecodeString(r[2])
+		copy(v.pub[:], pub)
+		v.auxRand, _ = hex.DecodeString(r[3])
+		v.msg, _ = hex.DecodeString(r[4])
+		v.sig, _ = hex.DecodeString(r[5])
+
+		vectors = append(vectors, v)
+	}
+
+	return vectors
+}
+
+func TestBIP340Vectors(t *testing.T) {
+	for _, v := range readBIP340Vectors(t) {
+		if len(v.priv) > 0 {
+			priv := big.NewInt(0).SetBytes(v.priv)
+			if got, err := NewXOnlyPubKey(Secp256k1Pub(priv)); err != nil || got != v.pub {
+				t.Errorf("vector %s: public key %x, %v, want %x", v.index, got, err, v.pub)
+			}
+
+			sig, err := SchnorrSign(priv, v.msg, v.auxRand)
+			if err != nil {
+				t.Errorf("vector %s: SchnorrSign: %v", v.index, err)
+			} else if !bytes.Equal(sig, v.sig) {
+				t.Errorf("vector %s: signature %x, want %x", v.index, sig, v.sig)
+			}
+		}
+
+		if _, err := ParseXOnlyPubKey(v.pub[:]); err != nil && v.valid {
+			t.Errorf("vector %s: ParseXOnlyPubKey: %v", v.index, err)
+		}
+
+		if got := SchnorrVerify(v.pub, v.msg, v.sig); got != v.valid {
+			t.Errorf("vector %s (%s): SchnorrVerify = %v, want %v", v.index, v.comment, got, v.valid)
+		}
+	}
+}
+
+func TestSchnorrBatchVerify(t *testing.T) {
+	vectors := readBIP340Vectors(t)
+
+	var pubs []XOnlyPubKey
+	var msgs, sigs [][]byte
+	for _, v := range vectors {
+		if v.valid {
+			pubs = append(pubs, v.pub)
+			msgs = append(msgs, v.msg)
+			sigs = append(sigs, v.sig)
+		}
+	}
+
+	ok, err := SchnorrBatchVerify(pubs, msgs, sigs)
+	if err != nil || !ok {
+		t.Fatalf("batch of the valid vectors: %v, %v", ok, err)
+	}
+
+	// a single invalid signature anywhere in the batch fails it
+	for _, v := range vectors {
+		if v.valid {
+			continue
+		}
+
+		for _, at := range []int{0, len(pubs)} {
+			batchPubs := append(append(append([]XOnlyPubKey{}, pubs[:at]...), v.pub), pubs[at:]...)
+			batchMsgs := append(append(append([][]byte{}, msgs[:at]...), v.msg), msgs[at:]...)
+			batchSigs := append(append(append([][]byte{}, sigs[:at]...), v.sig), sigs[at:]...)
+
+			ok, err := SchnorrBatchVerify(batchPubs, batchMsgs, batchSigs)
+			if err != nil || ok {
+				t.Errorf("vector %s (%s) at %d: batch verified: %v, %v", v.index, v.comment, at, ok, err)
+			}
+		}
+	}
+
+	if ok, err := SchnorrBatchVerify(nil, nil, nil); err != nil || !ok {
+		t.Errorf("empty batch: %v, %v", ok, err)
+	}
+
+	if _, err := SchnorrBatchVerify(pubs, msgs[1:], sigs); err == nil {
+		t.Errorf("batch of mismatched sizes accepted")
+	}
+}
+
+func BenchmarkSchnorrSign(b *testing.B) {
+	msg := make([]byte, 32)
+	auxRand := make([]byte, 32)
+	for range b.N {
+		SchnorrSign(benchmarkKey, msg, auxRand)
+	}
+}
+
+func BenchmarkSchnorrVerify(b *testing.B) {
+	msg := make([]byte, 32)
+	sig, _ := SchnorrSign(benchmarkKey, msg, make([]byte, 32))
+	pub, _ := NewXOnlyPubKey(Secp256k1Pub(benchmarkKey))
+	for range b.N {
+		SchnorrVerify(pub, msg, sig)
+	}
+}
@@ -1,63 +1,16 @@
 package btools
 
 import (
+	"errors"
+	"fmt"
 	"math/big"
 )
 
-/*
-C:	y^2 = x^3 + 7   (mod p)
+// Points are exposed as affine coordinates with math/big integers, but the
+// arithmetic runs on the constant-time fieldElement, scalar and
+// jacobianPoint types.
 
-p0 = (x0, y0)
-p1 = (x1, y1)
-p2 = p0 + p1 = (x2, y2)
-
-
-y = sl(x) = m*x + b
-b = y0 - m*x0
-
-(m*x+b)^2 = x^3 + 7
-
-(x-x0)*(x-x1)*(x-x2) = (x^3) - (m^2)*(x^2) - (2*m*b)*x + 7 - (b^2)
-
-(x-x0)*(x-x1)*(x-x2)
-(x^2 - x*(x0+x1) + x0*x1)*(x-x2)
-x^3 - x2*(x^2) - (x0+x1)*(x^2) + ((x0+x1)*x2+x0*x1)*x - x0*x1*x2
-
-
-(x^3) - (x0+x1+x2)*(x^2) + ((x0+x1)*x2+x0*x1)*x - x0*x1*x2
-==
-(x^3) - (m^2)*(x^2)      - (2*m*b)*x            + 7 - (b^2)
-
-
-==>
-
-m^2 = x0+x1+x2 ==> x2 = m^2-x0-x1
-y2 = m*x2 + b
-
----------------- p0.x != p1.x ----------------
----------------- p0.x != p1.x ----------------
----------------- p0.x != p1.x ----------------
-y = sl(x) = m*x + b
-
-m = (y0 - y1)/(x0 - x1)
-b = y0 - m*x0
-
----------------- p0 == p1 ----------------
----------------- p0 == p1 ----------------
----------------- p0 == p1 ----------------
-y = tl(x) = m*x + b
-
-(2*y)*m = 3*(x^2)
-m = 3*(x^2)/(2*y)
-
-tl(x0) = y0 = m*x0 + b
-b = y0 - m*x0
-
----------------- p0.x == p1.x && p0.y == -p1.y ----------------
----------------- p0.x == p1.x && p0.y == -p1.y ----------------
----------------- p0.x == p1.x && p0.y == -p1.y ----------------
-(0, 0)
-*/
+var ErrPointAtInfinity = errors.New("point at infinity")
 
 var secp256k1Order *big.Int
 var p *big.Int
@@ -79,17 +32,30 @@ func init() {
 	g = Point{X: gx, Y: gy}
 }
 
+// Point is an affine point of secp256k1. The point at infinity, the
+// identity of the group, has no affine coordinates: it is only returned by
+// Infinity and its X and Y are nil.
 type Point struct {
 	X *big.Int
 	Y *big.Int
+
+	infinity bool
 }
 
-var infinity Point = Point{
-	X: big.NewInt(0),
-	Y: big.NewInt(0),
+// Infinity returns the point at infinity.
+func Infinity() Point {
+	return Point{infinity: true}
+}
+
+func (p0 Point) IsInfinity() bool {
+	return p0.infinity
 }
 
 func (p0 Point) Equal(p1 Point) bool {
+	if p0.infinity || p1.infinity {
+		return p0.infinity == p1.infinity
+	}
+
 	return p0.X != nil && p1.X != nil &&
 		p0.X.Cmp(p1.X) == 0 &&
 		p0.Y != nil && p1.Y != nil &&
@@ -97,6 +63,15 @@ func (p0 Point) Equal(p1 Point) bool {
 }
 
 func (p0 Point) Dup() Point {
+	if p0.infinity {
+		return Infinity()
+	}
+
+	// the zero Point, or any point missing a coordinate, is copied as Point{}
+	if p0.X == nil || p0.Y == nil {
+		return Point{}
+	}
+
 	x := big.NewInt(0)
 	x.Add(x, p0.X)
 
@@ -106,143 +81,139 @@ func (p0 Point) Dup() Point {
 	return Point{X: x, Y: y}
 }
 
-func (p0 Point) Inverse() Point {
-	inv := p0.Dup()
-	inv.Y.Sub(p, inv.Y)
-
-	return inv
-}
-
-func Secp256k1Add(p0, p1 Point) Point {
-	zero := big.NewInt(0)
-	if p0.Equal(infinity) {
-		return p1.Dup()
-	}
-
-	if p1.Equal(infinity) {
-		return p0.Dup()
+// Inverse returns -p0. p0 must be on the curve or the point at infinity,
+// the zero Point in particular is rejected.
+func (p0 Point) Inverse() (Point, error) {
+	if p0.infinity {
+		return Infinity(), nil
 	}
 
-	invP1 := p1.Inverse()
-	if p0.Equal(invP1) {
-		return infinity.Dup()
+	if !p0.IsOnCurve() {
+		return Point{}, fmt.Errorf("invalid point: point is not on the curve")
 	}
 
-	if p0.Equal(p1) {
-		/*
-			y = tl(x) = m*x + b
-
-			(2*y)*m = 3*(x^2)
-			m = 3*(x^2)/(2*y)
-
-			tl(x0) = y0 = m*x0 + b
-			b = y0 - m*x0
-
-			m^2 = x0+x1+x2 ==> x2 = m^2-x0-x1
-			y2 = m*x2 + b
-		*/
+	inv := p0.Dup()
+	inv.Y.Sub(p, inv.Y).Mod(inv.Y, p)
 
-		if p0.Y.Cmp(zero) == 0 {
-			return infinity.Dup()
-		}
+	return inv, nil
+}
 
-		denom := big.NewInt(2)
-		denom.Mul(denom, p0.Y).Mod(denom, p)
-		denom.ModInverse(denom, p)
+func Secp256k1Add(p0, p1 Point) Point {
+	return jacobianFromPoint(p0).add(jacobianFromPoint(p1)).point()
+}
 
-		m := big.NewInt(3)
-		m.Mul(m, p0.X).Mod(m, p)
-		m.Mul(m, p0.X).Mod(m, p)
-		m.Mul(m, denom).Mod(m, p)
+// Secp256k1Mul computes n*p0 in constant time with respect to n.
+func Secp256k1Mul(n *big.Int, p0 Point) Point {
+	return jacobianFromPoint(p0).mulGLV(scalarFromBig(n)).point()
+}
 
-		mx0 := big.NewInt(0)
-		mx0.Add(mx0, m).Mul(mx0, p0.X).Mod(mx0, p)
+// IsOnCurve tells whether p0 is a point of secp256k1 other than the point at
+// infinity.
+func (p0 Point) IsOnCurve() bool {
+	if p0.infinity || p0.X == nil || p0.Y == nil {
+		return false
+	}
 
-		b := big.NewInt(0)
-		b.Add(b, p0.Y).Sub(b, mx0).Mod(b, p)
+	if p0.X.Sign() < 0 || p0.X.Cmp(p) >= 0 || p0.Y.Sign() < 0 || p0.Y.Cmp(p) >= 0 {
+		return false
+	}
 
-		x2 := big.NewInt(0)
-		x2.Add(x2, m).Mul(x2, m).Sub(x2, p0.X).Sub(x2, p0.X).Mod(x2, p)
+	// y^2 = x^3 + 7
+	x := fieldFromBig(p0.X)
+	y := fieldFromBig(p0.Y)
 
-		y2 := big.NewInt(0)
-		y2.Add(y2, m).Mul(y2, x2).Add(y2, b).Mod(y2, p)
+	return y.square().equal(x.square().mul(x).add(fieldElement{7, 0, 0, 0})) == 1
+}
 
-		y2.Sub(p, y2)
+// Secp256k1Compressed returns the 33 bytes compressed SEC encoding of p0.
+func Secp256k1Compressed(p0 Point) ([]byte, error) {
+	if p0.infinity {
+		return nil, fmt.Errorf("invalid public key: %w", ErrPointAtInfinity)
+	}
 
-		return Point{X: x2, Y: y2}
+	if !p0.IsOnCurve() {
+		return nil, fmt.Errorf("invalid public key: point is not on the curve")
 	}
 
-	/*
-		y = sl(x) = m*x + b
+	return compressPoint(p0), nil
+}
 
-		m = (y0 - y1)/(x0 - x1)
-		b = y0 - m*x0
+// Secp256k1Uncompressed returns the 65 bytes uncompressed SEC encoding of p0.
+func Secp256k1Uncompressed(p0 Point) ([]byte, error) {
+	if p0.infinity {
+		return nil, fmt.Errorf("invalid public key: %w", ErrPointAtInfinity)
+	}
 
-			m^2 = x0+x1+x2 ==> x2 = m^2-x0-x1
-			y2 = m*x2 + b
-	*/
-	denom := big.NewInt(0)
-	denom.Add(denom, p0.X).Sub(denom, p1.X).ModInverse(denom, p)
-	m := big.NewInt(0)
-	m.Add(m, p0.Y).Sub(m, p1.Y).Mul(m, denom).Mod(m, p)
+	if !p0.IsOnCurve() {
+		return nil, fmt.Errorf("invalid public key: point is not on the curve")
+	}
 
-	mx0 := big.NewInt(0)
-	mx0.Add(mx0, m).Mul(mx0, p0.X).Mod(mx0, p)
+	result := make([]byte, 65)
+	result[0] = 0x04
+	p0.X.FillBytes(result[1:33])
+	p0.Y.FillBytes(result[33:])
 
-	b := big.NewInt(0)
-	b.Add(b, p0.Y).Mod(b, p)
-	b.Sub(b, mx0).Mod(b, p)
+	return result, nil
+}
 
-	x2 := big.NewInt(0)
-	x2.Add(x2, m).Mul(x2, m).Sub(x2, p0.X).Sub(x2, p1.X).Mod(x2, p)
+// ParsePublicKey parses a compressed or uncompressed SEC public key.
+func ParsePublicKey(data []byte) (Point, error) {
+	switch {
+	case len(data) == 33 && (data[0] == 0x02 || data[0] == 0x03):
+		return decompressPoint(data)
 
-	y2 := big.NewInt(0)
-	y2.Add(y2, m).Mul(y2, x2).Add(y2, b).Mod(y2, p)
+	case len(data) == 65 && data[0] == 0x04:
+		pub := Point{
+			X: big.NewInt(0).SetBytes(data[1:33]),
+			Y: big.NewInt(0).SetBytes(data[33:]),
+		}
+		if !pub.IsOnCurve() {
+			return Point{}, fmt.Errorf("invalid public key: point is not on the curve")
+		}
 
-	y2.Sub(p, y2)
+		return pub, nil
+	}
 
-	return Point{X: x2, Y: y2}
+	return Point{}, fmt.Errorf("invalid public key: unknown encoding of %d bytes", len(data))
 }
 
-func Secp256k1Mul(n *big.Int, p0 Point) Point {
-	result := infinity.Dup()
-
-	bytes := n.Bytes()
-	for _, b := range bytes {
-		for range 8 {
-			bit := (0b10000000 & b) >> 7
-			b = b << 1
-
-			result = Secp256k1Add(result, result)
-			if bit == 1 {
-				result = Secp256k1Add(result, p0)
-			}
-		}
-	}
+// compressPoint serializes p0 without validating it, for points known to be
+// on the curve such as the public keys of valid private keys.
+func compressPoint(p0 Point) []byte {
+	result := make([]byte, 33)
+	result[0] = 0x02 | byte(p0.Y.Bit(0))
+	p0.X.FillBytes(result[1:])
+
 	return result
 }
 
-func Secp256k1Compressed(p0 Point) []byte {
-	result := make([]byte, 33)
-	result[0] = 0x03
+// decompressPoint parses a compressed SEC public key, recovering y from
+// y^2 = x^3 + 7.
+func decompressPoint(data []byte) (Point, error) {
+	if len(data) != 33 || (data[0] != 0x02 && data[0] != 0x03) {
+		return Point{}, fmt.Errorf("invalid compressed public key")
+	}
 
-	xBytes := p0.X.Bytes()
-	copy(result[1:], xBytes)
+	x := big.NewInt(0).SetBytes(data[1:])
+	if x.Cmp(p) >= 0 {
+		return Point{}, fmt.Errorf("invalid public key: x is not a field element")
+	}
 
-	parity := big.NewInt(0)
-	parity.Mod(p0.Y, big.NewInt(2))
+	xf := fieldFromBytes(data[1:])
+	rhs := xf.square().mul(xf).add(fieldElement{7, 0, 0, 0})
 
-	if parity.Cmp(big.NewInt(0)) == 0 {
-		result[0] = 0x02
+	// p = 3 mod 4, so a square root of a is a^((p+1)/4)
+	y, ok := rhs.sqrt()
+	if ok == 0 {
+		return Point{}, fmt.Errorf("invalid public key: point is not on the curve")
 	}
 
-	return result
-}
+	y = y.choose(y.neg(), y.isOdd()^uint64(data[0]&1))
 
-func Secp256k1Pub(k *big.Int) Point {
-	return Secp256k1Mul(k, g)
+	return Point{X: x, Y: y.big()}, nil
 }
 
-func Infinity() Point {
-	return infinity.Dup()
+// Secp256k1Pub computes k*G in constant time with respect to k.
+func Secp256k1Pub(k *big.Int) Point {
+	return mulGenerator(scalarFromBig(k)).point()
 }
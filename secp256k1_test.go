@@ -0,0 +1,231 @@
+package btools
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+	"testing/quick"
+)
+
+var benchmarkKey = hexInt("c0ffee254729296a45a3885639ac7e10f9d54979c0ffee254729296a45a38856")
+
+// quickPoint maps random bytes to a point, the identity included when the
+// bytes are a multiple of n.
+func quickPoint(b []byte) Point {
+	return Secp256k1Pub(big.NewInt(0).SetBytes(b))
+}
+
+func TestGroupLaw(t *testing.T) {
+	inf := Infinity()
+	config := &quick.Config{MaxCount: 50}
+
+	properties := []struct {
+		name string
+		f    any
+	}{
+		{"identity", func(a []byte) bool {
+			p0 := quickPoint(a)
+			return Secp256k1Add(p0, inf).Equal(p0) && Secp256k1Add(inf, p0).Equal(p0)
+		}},
+		{"inverse", func(a []byte) bool {
+			p0 := quickPoint(a)
+			inv, err := p0.Inverse()
+			return err == nil && Secp256k1Add(p0, inv).IsInfinity()
+		}},
+		{"doubling", func(a []byte) bool {
+			p0 := quickPoint(a)
+			return Secp256k1Add(p0, p0).Equal(naiveAdd(p0, p0)) && Secp256k1Mul(big.NewInt(2), p0).Equal(naiveAdd(p0, p0))
+		}},
+		{"copy", func(a []byte) bool {
+			p0 := quickPoint(a)
+			p1 := p0.Dup()
+			if p0.IsInfinity() {
+				return p1.IsInfinity()
+			}
+
+			p1.X.Add(p1.X, big.NewInt(1))
+			return p1.X.Cmp(p0.X) != 0
+		}},
+		{"commutativity", func(a, b []byte) bool {
+			p0, p1 := quickPoint(a), quickPoint(b)
+			return Secp256k1Add(p0, p1).Equal(Secp256k1Add(p1, p0))
+		}},
+		{"associativity", func(a, b, c []byte) bool {
+			p0, p1, p2 := quickPoint(a), quickPoint(b), quickPoint(c)
+			return Secp256k1Add(Secp256k1Add(p0, p1), p2).Equal(Secp256k1Add(p0, Secp256k1Add(p1, p2)))
+		}},
+		{"distributivity", func(a, b, c []byte) bool {
+			p0 := quickPoint(c)
+			k0, k1 := big.NewInt(0).SetBytes(a), big.NewInt(0).SetBytes(b)
+			sum := Secp256k1Mul(big.NewInt(0).Add(k0, k1), p0)
+			return sum.Equal(Secp256k1Add(Secp256k1Mul(k0, p0), Secp256k1Mul(k1, p0)))
+		}},
+		{"order", func(a []byte) bool {
+			p0 := quickPoint(a)
+			return Secp256k1Mul(secp256k1Order, p0).IsInfinity() && p0.IsInfinity() != p0.IsOnCurve()
+		}},
+	}
+
+	for _, prop := range properties {
+		if err := quick.Check(prop.f, config); err != nil {
+			t.Errorf("%s: %v", prop.name, err)
+		}
+	}
+}
+
+func TestPointAtInfinity(t *testing.T) {
+	inf := Infinity()
+
+	if inv, err := inf.Inverse(); err != nil || !inv.IsInfinity() || !inf.Dup().IsInfinity() || !Secp256k1Mul(big.NewInt(5), inf).IsInfinity() {
+		t.Errorf("the point at infinity is not absorbing")
+	}
+
+	if inf.IsOnCurve() {
+		t.Errorf("the point at infinity is on the curve")
+	}
+
+	if !Secp256k1Pub(big.NewInt(0)).IsInfinity() || !Secp256k1Pub(secp256k1Order).IsInfinity() {
+		t.Errorf("0*G and n*G are not the point at infinity")
+	}
+
+	if (Point{X: big.NewInt(0), Y: big.NewInt(0)}).Equal(inf) {
+		t.Errorf("(0, 0) equals the point at infinity")
+	}
+
+	errs := map[string]func() error{
+		"Secp256k1Compressed": func() error {
+			_, err := Secp256k1Compressed(inf)
+			return err
+		},
+		"Secp256k1Uncompressed": func() error {
+			_, err := Secp256k1Uncompressed(inf)
+			return err
+		},
+		"NewXOnlyPubKey": func() error {
+			_, err := NewXOnlyPubKey(inf)
+			return err
+		},
+		"TaprootOutputKey": func() error {
+			_, err := TaprootOutputKey(inf)
+			return err
+		},
+		"Address": func() error {
+			_, err := Address(inf, AddressP2WPKH, Mainnet)
+			return err
+		},
+		"CKDpub": func() error {
+			_, err := XPubKey{PublicKey: inf, ChainCode: make([]byte, 32)}.CKDpub(0)
+			return err
+		},
+		"SerializeKeyVersions": func() error {
+			_, err := XPubKey{PublicKey: inf, ChainCode: make([]byte, 32)}.SerializeKeyVersions(XPubVersions)
+			return err
+		},
+		"Identifier": func() error {
+			_, err := XPubKey{PublicKey: inf}.Identifier()
+			return err
+		},
+		"Secp256k1Identifier": func() error {
+			_, err := Secp256k1Identifier(big.NewInt(0))
+			return err
+		},
+	}
+
+	for name, f := range errs {
+		if err := f(); !errors.Is(err, ErrPointAtInfinity) {
+			t.Errorf("%s(infinity) returned %v, want ErrPointAtInfinity", name, err)
+		}
+	}
+
+	if _, err := NewXOnlyPubKey(Point{X: big.NewInt(1), Y: big.NewInt(1)}); err == nil {
+		t.Errorf("NewXOnlyPubKey accepted a point off the curve")
+	}
+
+	// 0 and n are the keys of the point at infinity
+	for _, k := range []*big.Int{nil, big.NewInt(0), secp256k1Order} {
+		xpriv := XPrivKey{PrivateKey: k, ChainCode: make([]byte, 32)}
+		if _, err := xpriv.CKDpriv(0); err == nil {
+			t.Errorf("CKDpriv accepted the private key %v", k)
+		}
+
+		if _, err := xpriv.SerializeKeyVersions(XPubVersions); err == nil {
+			t.Errorf("SerializeKeyVersions accepted the private key %v", k)
+		}
+
+		if _, err := xpriv.Identifier(); err == nil {
+			t.Errorf("Identifier accepted the private key %v", k)
+		}
+	}
+}
+
+func TestZeroPoint(t *testing.T) {
+	var zero Point
+
+	if zero.IsInfinity() || zero.IsOnCurve() || zero.Equal(Infinity()) {
+		t.Errorf("the zero Point is a valid point")
+	}
+
+	if dup := zero.Dup(); dup.IsInfinity() || dup.X != nil || dup.Y != nil {
+		t.Errorf("Dup of the zero Point = %+v", dup)
+	}
+
+	xpub := XPubKey{PublicKey: zero, ChainCode: make([]byte, 32)}
+
+	errs := map[string]func() error{
+		"Inverse": func() error {
+			_, err := zero.Inverse()
+			return err
+		},
+		"TaprootOutputKey": func() error {
+			_, err := TaprootOutputKey(zero)
+			return err
+		},
+		"NewXOnlyPubKey": func() error {
+			_, err := NewXOnlyPubKey(zero)
+			return err
+		},
+		"Address": func() error {
+			_, err := Address(zero, AddressP2TR, Mainnet)
+			return err
+		},
+		"CKDpub": func() error {
+			_, err := xpub.CKDpub(0)
+			return err
+		},
+		"SerializeKeyVersions": func() error {
+			_, err := xpub.SerializeKeyVersions(XPubVersions)
+			return err
+		},
+		"Identifier": func() error {
+			_, err := xpub.Identifier()
+			return err
+		},
+	}
+
+	for name, f := range errs {
+		if err := f(); err == nil {
+			t.Errorf("%s accepted the zero Point", name)
+		}
+	}
+}
+
+func BenchmarkSecp256k1Pub(b *testing.B) {
+	for range b.N {
+		Secp256k1Pub(benchmarkKey)
+	}
+}
+
+func BenchmarkSecp256k1Mul(b *testing.B) {
+	pt := Secp256k1Pub(big.NewInt(12345))
+	for range b.N {
+		Secp256k1Mul(benchmarkKey, pt)
+	}
+}
+
+func BenchmarkSecp256k1Add(b *testing.B) {
+	p0 := Secp256k1Pub(big.NewInt(12345))
+	p1 := Secp256k1Pub(benchmarkKey)
+	for range b.N {
+		Secp256k1Add(p0, p1)
+	}
+}
@@ -0,0 +1,75 @@
+package btools
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+const (
+	sighashDefault = 0x00
+	sighashAll     = 0x01
+)
+
+// sighashWitnessV0 returns the BIP143 signature hash of input i.
+func sighashWitnessV0(tx Tx, i int, scriptCode []byte, amount uint64, hashType byte) []byte {
+	var prevouts, sequences, outputs []byte
+	for _, in := range tx.Inputs {
+		prevouts = in.PrevOut.append(prevouts)
+		sequences = binary.LittleEndian.AppendUint32(sequences, in.Sequence)
+	}
+	for _, out := range tx.Outputs {
+		outputs = out.append(outputs)
+	}
+
+	in := tx.Inputs[i]
+
+	data := binary.LittleEndian.AppendUint32(nil, tx.Version)
+	data = append(data, doubleSHA256(prevouts)...)
+	data = append(data, doubleSHA256(sequences)...)
+	data = in.PrevOut.append(data)
+	data = appendVarBytes(data, scriptCode)
+	data = binary.LittleEndian.AppendUint64(data, amount)
+	data = binary.LittleEndian.AppendUint32(data, in.Sequence)
+	data = append(data, doubleSHA256(outputs)...)
+	data = binary.LittleEndian.AppendUint32(data, tx.LockTime)
+	data = binary.LittleEndian.AppendUint32(data, uint32(hashType))
+
+	return doubleSHA256(data)
+}
+
+// sighashTaproot returns the BIP341 signature hash of input i for a key path
+// spend without annex, given the scripts and amounts of every spent output.
+// Only SIGHASH_DEFAULT and SIGHASH_ALL are supported.
+func sighashTaproot(tx Tx, i int, scripts [][]byte, amounts []uint64, hashType byte) []byte {
+	var prevouts, sequences, outputs, amountsData, scriptsData []byte
+	for j, in := range tx.Inputs {
+		prevouts = in.PrevOut.append(prevouts)
+		sequences = binary.LittleEndian.AppendUint32(sequences, in.Sequence)
+		amountsData = binary.LittleEndian.AppendUint64(amountsData, amounts[j])
+		scriptsData = appendVarBytes(scriptsData, scripts[j])
+	}
+	for _, out := range tx.Outputs {
+		outputs = out.append(outputs)
+	}
+
+	// epoch 0
+	data := []byte{0x00, hashType}
+	data = binary.LittleEndian.AppendUint32(data, tx.Version)
+	data = binary.LittleEndian.AppendUint32(data, tx.LockTime)
+	data = append(data, sha256Sum(prevouts)...)
+	data = append(data, sha256Sum(amountsData)...)
+	data = append(data, sha256Sum(scriptsData)...)
+	data = append(data, sha256Sum(sequences)...)
+	data = append(data, sha256Sum(outputs)...)
+
+	// spend type: key path, no annex
+	data = append(data, 0x00)
+	data = binary.LittleEndian.AppendUint32(data, uint32(i))
+
+	return TaggedHash("TapSighash", data)
+}
+
+func sha256Sum(data []byte) []byte {
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
@@ -0,0 +1,91 @@
+package btools
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// KeyVersions holds the version bytes used to serialize a pair of extended
+// private and public keys. Besides the BIP32 xpub and tpub versions, SLIP-132
+// defines versions that tell wallets which script type the account uses.
+type KeyVersions struct {
+	// Name is the prefix of the serialized public key, e.g. "zpub".
+	Name    string
+	Private []byte
+	Public  []byte
+}
+
+var (
+	XPubVersions         = KeyVersions{"xpub", []byte{0x04, 0x88, 0xAD, 0xE4}, []byte{0x04, 0x88, 0xB2, 0x1E}}
+	YPubVersions         = KeyVersions{"ypub", []byte{0x04, 0x9D, 0x78, 0x78}, []byte{0x04, 0x9D, 0x7C, 0xB2}}
+	ZPubVersions         = KeyVersions{"zpub", []byte{0x04, 0xB2, 0x43, 0x0C}, []byte{0x04, 0xB2, 0x47, 0x46}}
+	YPubMultisigVersions = KeyVersions{"Ypub", []byte{0x02, 0x95, 0xB0, 0x05}, []byte{0x02, 0x95, 0xB4, 0x3F}}
+	ZPubMultisigVersions = KeyVersions{"Zpub", []byte{0x02, 0xAA, 0x7A, 0x99}, []byte{0x02, 0xAA, 0x7E, 0xD3}}
+
+	TPubVersions         = KeyVersions{"tpub", []byte{0x04, 0x35, 0x83, 0x94}, []byte{0x04, 0x35, 0x87, 0xCF}}
+	UPubVersions         = KeyVersions{"upub", []byte{0x04, 0x4A, 0x4E, 0x28}, []byte{0x04, 0x4A, 0x52, 0x62}}
+	VPubVersions         = KeyVersions{"vpub", []byte{0x04, 0x5F, 0x18, 0xBC}, []byte{0x04, 0x5F, 0x1C, 0xF6}}
+	UPubMultisigVersions = KeyVersions{"Upub", []byte{0x02, 0x42, 0x85, 0xB5}, []byte{0x02, 0x42, 0x89, 0xEF}}
+	VPubMultisigVersions = KeyVersions{"Vpub", []byte{0x02, 0x57, 0x50, 0x48}, []byte{0x02, 0x57, 0x54, 0x83}}
+)
+
+var KeyVersionSets = []KeyVersions{
+	XPubVersions,
+	YPubVersions,
+	ZPubVersions,
+	YPubMultisigVersions,
+	ZPubMultisigVersions,
+	TPubVersions,
+	UPubVersions,
+	VPubVersions,
+	UPubMultisigVersions,
+	VPubMultisigVersions,
+}
+
+// GetKeyVersions returns the version set whose public key prefix is name,
+// e.g. "zpub" or "Vpub". The private key prefix, e.g. "zprv", is accepted too.
+func GetKeyVersions(name string) (KeyVersions, error) {
+	for _, v := range KeyVersionSets {
+		if v.Name == name || v.Name[:1]+"prv" == name {
+			return v, nil
+		}
+	}
+
+	return KeyVersions{}, fmt.Errorf("unknown extended key version set: %q", name)
+}
+
+// KeyVersionsForPurpose returns the version set used by net for single
+// signature accounts of the given BIP44 style purpose (44, 49, 84 or 86).
+func KeyVersionsForPurpose(purpose uint32, net Network) (KeyVersions, error) {
+	versions, ok := net.PurposeKeyVersions[purpose]
+	if !ok {
+		return KeyVersions{}, fmt.Errorf("unsupported purpose for %s: %d", net.Name, purpose)
+	}
+
+	return versions, nil
+}
+
+func lookupKeyVersions(version []byte) (KeyVersions, bool, bool) {
+	for _, v := range KeyVersionSets {
+		if bytes.Equal(version, v.Private) {
+			return v, true, true
+		}
+
+		if bytes.Equal(version, v.Public) {
+			return v, false, true
+		}
+	}
+
+	return KeyVersions{}, false, false
+}
+
+// ConvertExtendedKey re-serializes an extended key with another version set,
+// e.g. to turn an xpub into the equivalent zpub.
+func ConvertExtendedKey(s string, to KeyVersions) (string, error) {
+	key, _, err := ParseExtendedKey(s)
+	if err != nil {
+		return "", err
+	}
+
+	return key.SerializeKeyVersions(to)
+}
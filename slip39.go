@@ -0,0 +1,601 @@
+package btools
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	slip39RadixBits          = 10
+	slip39IDBits             = 15
+	slip39IterationExpBits   = 4
+	slip39IDExpWords         = 2
+	slip39ChecksumWords      = 3
+	slip39DigestLength       = 4
+	slip39MetadataWords      = slip39IDExpWords + 2 + slip39ChecksumWords
+	slip39MinStrengthBits    = 128
+	slip39MinMnemonicWords   = slip39MetadataWords + (slip39MinStrengthBits+slip39RadixBits-1)/slip39RadixBits
+	slip39BaseIterationCount = 10000
+	slip39RoundCount         = 4
+	slip39SecretIndex        = 255
+	slip39DigestIndex        = 254
+	slip39MaxShareCount      = 16
+)
+
+var slip39WordIndex map[string]int
+
+var slip39Exp [255]byte
+var slip39Log [256]byte
+
+func init() {
+	slip39WordIndex = make(map[string]int, len(slip39Wordlist))
+	for i, w := range slip39Wordlist {
+		slip39WordIndex[w] = i
+	}
+
+	// GF(256) with the Rijndael polynomial x^8 + x^4 + x^3 + x + 1 and 3 as
+	// generator
+	poly := 1
+	for i := range 255 {
+		slip39Exp[i] = byte(poly)
+		slip39Log[poly] = byte(i)
+
+		poly = (poly << 1) ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11B
+		}
+	}
+}
+
+// SLIP39Group describes how the share of a group is split among its
+// members: Threshold of the Count member shares are required to recover it.
+type SLIP39Group struct {
+	Threshold int
+	Count     int
+}
+
+type slip39Share struct {
+	identifier        int
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+type slip39RawShare struct {
+	x    byte
+	data []byte
+}
+
+// SLIP39Split splits a master secret into SLIP-39 mnemonic shares protected
+// by passphrase. groupThreshold of the groups are required to recover the
+// secret. The result holds the mnemonics of every member of every group.
+func SLIP39Split(secret []byte, passphrase string, groupThreshold int, groups []SLIP39Group, extendable bool, iterationExponent int) ([][][]string, error) {
+	if len(secret)*8 < slip39MinStrengthBits {
+		return nil, fmt.Errorf("the master secret must be at least %d bits long", slip39MinStrengthBits)
+	}
+
+	if len(secret)%2 != 0 {
+		return nil, fmt.Errorf("the master secret length in bytes must be even")
+	}
+
+	if iterationExponent < 0 || iterationExponent >= 1<<slip39IterationExpBits {
+		return nil, fmt.Errorf("invalid iteration exponent: %d", iterationExponent)
+	}
+
+	if groupThreshold > len(groups) {
+		return nil, fmt.Errorf("the group threshold (%d) cannot exceed the number of groups (%d)", groupThreshold, len(groups))
+	}
+
+	for _, g := range groups {
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, fmt.Errorf("creating multiple member shares with member threshold 1 is not allowed, use 1-of-1 member sharing instead")
+		}
+	}
+
+	if err := slip39CheckPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	idBytes := make([]byte, 2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return nil, err
+	}
+	identifier := int(binary.BigEndian.Uint16(idBytes)) & (1<<slip39IDBits - 1)
+
+	ems := slip39Encrypt(secret, passphrase, iterationExponent, identifier, extendable)
+
+	groupShares, err := slip39SplitSecret(groupThreshold, len(groups), ems)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][][]string, len(groups))
+	for i, g := range groups {
+		memberShares, err := slip39SplitSecret(g.Threshold, g.Count, groupShares[i].data)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ms := range memberShares {
+			share := slip39Share{
+				identifier:        identifier,
+				extendable:        extendable,
+				iterationExponent: iterationExponent,
+				groupIndex:        int(groupShares[i].x),
+				groupThreshold:    groupThreshold,
+				groupCount:        len(groups),
+				memberIndex:       int(ms.x),
+				memberThreshold:   g.Threshold,
+				value:             ms.data,
+			}
+
+			result[i] = append(result[i], share.mnemonic())
+		}
+	}
+
+	return result, nil
+}
+
+// SLIP39Combine recovers the master secret from a set of SLIP-39 mnemonic
+// shares.
+func SLIP39Combine(mnemonics [][]string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, fmt.Errorf("the set of shares is empty")
+	}
+
+	if err := slip39CheckPassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	var first slip39Share
+	groups := map[int][]slip39Share{}
+	groupOrder := []int{}
+	for i, m := range mnemonics {
+		share, err := parseSLIP39Share(m)
+		if err != nil {
+			return nil, err
+		}
+
+		if i == 0 {
+			first = share
+		}
+
+		if share.identifier != first.identifier ||
+			share.extendable != first.extendable ||
+			share.iterationExponent != first.iterationExponent ||
+			share.groupThreshold != first.groupThreshold ||
+			share.groupCount != first.groupCount {
+			return nil, fmt.Errorf("all mnemonics must begin with the same %d words, must have the same group threshold and the same group count", slip39IDExpWords)
+		}
+
+		group, ok := groups[share.groupIndex]
+		if !ok {
+			groupOrder = append(groupOrder, share.groupIndex)
+		}
+
+		duplicate := false
+		for _, s := range group {
+			if s.memberThreshold != share.memberThreshold {
+				return nil, fmt.Errorf("all mnemonics in a group must have the same member threshold")
+			}
+
+			if s.memberIndex == share.memberIndex && bytes.Equal(s.value, share.value) {
+				duplicate = true
+			}
+		}
+
+		if !duplicate {
+			groups[share.groupIndex] = append(group, share)
+		}
+	}
+
+	if len(groups) < first.groupThreshold {
+		return nil, fmt.Errorf("insufficient number of mnemonic groups: %d of %d required", len(groups), first.groupThreshold)
+	}
+
+	if len(groups) != first.groupThreshold {
+		return nil, fmt.Errorf("wrong number of mnemonic groups: expected %d groups, but %d were provided", first.groupThreshold, len(groups))
+	}
+
+	groupShares := []slip39RawShare{}
+	for _, gi := range groupOrder {
+		group := groups[gi]
+		threshold := group[0].memberThreshold
+		if len(group) != threshold {
+			return nil, fmt.Errorf("wrong number of mnemonics in group %d: expected %d, but %d were provided", gi, threshold, len(group))
+		}
+
+		memberShares := make([]slip39RawShare, len(group))
+		for i, s := range group {
+			memberShares[i] = slip39RawShare{x: byte(s.memberIndex), data: s.value}
+		}
+
+		secret, err := slip39RecoverSecret(threshold, memberShares)
+		if err != nil {
+			return nil, err
+		}
+
+		groupShares = append(groupShares, slip39RawShare{x: byte(gi), data: secret})
+	}
+
+	ems, err := slip39RecoverSecret(first.groupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return slip39Decrypt(ems, passphrase, first.iterationExponent, first.identifier, first.extendable), nil
+}
+
+// SLIP39Seed recovers the master secret from a set of SLIP-39 mnemonic
+// shares as a Seed, so it can be used directly with MasterPrivateKey.
+func SLIP39Seed(mnemonics [][]string, passphrase string) (*Seed, error) {
+	secret, err := SLIP39Combine(mnemonics, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Seed{
+		Passphrase: passphrase,
+		Seed:       secret,
+	}, nil
+}
+
+func slip39CheckPassphrase(passphrase string) error {
+	for _, c := range []byte(passphrase) {
+		if c < 32 || c > 126 {
+			return fmt.Errorf("the passphrase must contain only printable ASCII characters")
+		}
+	}
+
+	return nil
+}
+
+func (s slip39Share) mnemonic() []string {
+	idExp := s.identifier<<(slip39IterationExpBits+1) | s.iterationExponent
+	if s.extendable {
+		idExp |= 1 << slip39IterationExpBits
+	}
+
+	params := s.groupIndex
+	params = params<<4 | (s.groupThreshold - 1)
+	params = params<<4 | (s.groupCount - 1)
+	params = params<<4 | s.memberIndex
+	params = params<<4 | (s.memberThreshold - 1)
+
+	valueWords := (len(s.value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	value := big.NewInt(0).SetBytes(s.value)
+
+	data := []int{}
+	data = append(data, slip39IntToWords(big.NewInt(int64(idExp)), slip39IDExpWords)...)
+	data = append(data, slip39IntToWords(big.NewInt(int64(params)), 2)...)
+	data = append(data, slip39IntToWords(value, valueWords)...)
+	data = append(data, slip39CreateChecksum(data, s.extendable)...)
+
+	words := make([]string, len(data))
+	for i, index := range data {
+		words[i] = slip39Wordlist[index]
+	}
+
+	return words
+}
+
+func parseSLIP39Share(mnemonic []string) (slip39Share, error) {
+	data := make([]int, len(mnemonic))
+	for i, w := range mnemonic {
+		index, ok := slip39WordIndex[w]
+		if !ok {
+			return slip39Share{}, UnknownWordError{Position: i, Word: w}
+		}
+
+		data[i] = index
+	}
+
+	if len(data) < slip39MinMnemonicWords {
+		return slip39Share{}, fmt.Errorf("%w: a SLIP-39 mnemonic must be at least %d words long", ErrInvalidWordCount, slip39MinMnemonicWords)
+	}
+
+	paddingBits := (slip39RadixBits * (len(data) - slip39MetadataWords)) % 16
+	if paddingBits > 8 {
+		return slip39Share{}, fmt.Errorf("%w: %d words", ErrInvalidWordCount, len(data))
+	}
+
+	idExp := int(slip39WordsToInt(data[:slip39IDExpWords]).Int64())
+	share := slip39Share{
+		identifier:        idExp >> (slip39IterationExpBits + 1),
+		extendable:        (idExp>>slip39IterationExpBits)&1 == 1,
+		iterationExponent: idExp & (1<<slip39IterationExpBits - 1),
+	}
+
+	if !slip39VerifyChecksum(data, share.extendable) {
+		return slip39Share{}, fmt.Errorf("%w: %s ...", ErrChecksumMismatch, mnemonic[0])
+	}
+
+	params := int(slip39WordsToInt(data[slip39IDExpWords : slip39IDExpWords+2]).Int64())
+	share.groupIndex = params >> 16
+	share.groupThreshold = (params>>12)&0xF + 1
+	share.groupCount = (params>>8)&0xF + 1
+	share.memberIndex = (params >> 4) & 0xF
+	share.memberThreshold = params&0xF + 1
+
+	if share.groupCount < share.groupThreshold {
+		return slip39Share{}, fmt.Errorf("the group threshold cannot be greater than the group count")
+	}
+
+	valueData := data[slip39IDExpWords+2 : len(data)-slip39ChecksumWords]
+	valueBytes := (slip39RadixBits*len(valueData) - paddingBits) / 8
+	value := slip39WordsToInt(valueData)
+	if value.BitLen() > valueBytes*8 {
+		return slip39Share{}, fmt.Errorf("invalid mnemonic padding")
+	}
+
+	share.value = value.FillBytes(make([]byte, valueBytes))
+
+	return share, nil
+}
+
+func slip39IntToWords(n *big.Int, count int) []int {
+	words := make([]int, count)
+	mask := big.NewInt(1<<slip39RadixBits - 1)
+	v := big.NewInt(0).Set(n)
+	for i := count - 1; i >= 0; i-- {
+		words[i] = int(big.NewInt(0).And(v, mask).Int64())
+		v.Rsh(v, slip39RadixBits)
+	}
+
+	return words
+}
+
+func slip39WordsToInt(words []int) *big.Int {
+	n := big.NewInt(0)
+	for _, w := range words {
+		n.Lsh(n, slip39RadixBits)
+		n.Or(n, big.NewInt(int64(w)))
+	}
+
+	return n
+}
+
+func slip39CustomizationString(extendable bool) []byte {
+	if extendable {
+		return []byte("shamir_extendable")
+	}
+
+	return []byte("shamir")
+}
+
+// slip39Polymod is the RS1024 checksum over GF(1024).
+func slip39Polymod(values []int) int {
+	gen := [10]int{
+		0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+		0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+	}
+
+	chk := 1
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<10 ^ v
+		for i := range 10 {
+			if (b>>i)&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func slip39ChecksumValues(data []int, extendable bool) []int {
+	values := []int{}
+	for _, c := range slip39CustomizationString(extendable) {
+		values = append(values, int(c))
+	}
+
+	return append(values, data...)
+}
+
+func slip39CreateChecksum(data []int, extendable bool) []int {
+	values := slip39ChecksumValues(data, extendable)
+	values = append(values, make([]int, slip39ChecksumWords)...)
+	polymod := slip39Polymod(values) ^ 1
+
+	checksum := make([]int, slip39ChecksumWords)
+	for i := range checksum {
+		checksum[i] = (polymod >> (slip39RadixBits * (slip39ChecksumWords - 1 - i))) & (1<<slip39RadixBits - 1)
+	}
+
+	return checksum
+}
+
+func slip39VerifyChecksum(data []int, extendable bool) bool {
+	return slip39Polymod(slip39ChecksumValues(data, extendable)) == 1
+}
+
+func slip39RoundFunction(i int, passphrase string, iterationExponent int, salt, r []byte) []byte {
+	password := append([]byte{byte(i)}, []byte(passphrase)...)
+	iterations := (slip39BaseIterationCount << iterationExponent) / slip39RoundCount
+	return pbkdf2.Key(password, append(append([]byte{}, salt...), r...), iterations, len(r), sha256.New)
+}
+
+func slip39Salt(identifier int, extendable bool) []byte {
+	if extendable {
+		return []byte{}
+	}
+
+	return binary.BigEndian.AppendUint16([]byte("shamir"), uint16(identifier))
+}
+
+// slip39Encrypt encrypts the master secret with a four round Feistel network
+// keyed by the passphrase.
+func slip39Encrypt(secret []byte, passphrase string, iterationExponent, identifier int, extendable bool) []byte {
+	l := append([]byte{}, secret[:len(secret)/2]...)
+	r := append([]byte{}, secret[len(secret)/2:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := range slip39RoundCount {
+		f := slip39RoundFunction(i, passphrase, iterationExponent, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+
+	return append(r, l...)
+}
+
+func slip39Decrypt(ems []byte, passphrase string, iterationExponent, identifier int, extendable bool) []byte {
+	l := append([]byte{}, ems[:len(ems)/2]...)
+	r := append([]byte{}, ems[len(ems)/2:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := slip39RoundCount - 1; i >= 0; i-- {
+		f := slip39RoundFunction(i, passphrase, iterationExponent, salt, r)
+		l, r = r, xorBytes(l, f)
+	}
+
+	return append(r, l...)
+}
+
+func xorBytes(a, b []byte) []byte {
+	result := make([]byte, len(a))
+	for i := range a {
+		result[i] = a[i] ^ b[i]
+	}
+
+	return result
+}
+
+func slip39Digest(randomData, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomData)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestLength]
+}
+
+func slip39SplitSecret(threshold, count int, secret []byte) ([]slip39RawShare, error) {
+	if threshold < 1 {
+		return nil, fmt.Errorf("the requested threshold must be a positive integer")
+	}
+
+	if threshold > count {
+		return nil, fmt.Errorf("the requested threshold (%d) must not exceed the number of shares (%d)", threshold, count)
+	}
+
+	if count > slip39MaxShareCount {
+		return nil, fmt.Errorf("the requested number of shares (%d) must not exceed %d", count, slip39MaxShareCount)
+	}
+
+	shares := []slip39RawShare{}
+	if threshold == 1 {
+		for i := range count {
+			shares = append(shares, slip39RawShare{x: byte(i), data: append([]byte{}, secret...)})
+		}
+		return shares, nil
+	}
+
+	randomShareCount := threshold - 2
+	for i := range randomShareCount {
+		data := make([]byte, len(secret))
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+		shares = append(shares, slip39RawShare{x: byte(i), data: data})
+	}
+
+	randomPart := make([]byte, len(secret)-slip39DigestLength)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, err
+	}
+
+	digest := slip39Digest(randomPart, secret)
+	baseShares := append([]slip39RawShare{}, shares...)
+	baseShares = append(baseShares,
+		slip39RawShare{x: slip39DigestIndex, data: append(digest, randomPart...)},
+		slip39RawShare{x: slip39SecretIndex, data: secret},
+	)
+
+	for i := randomShareCount; i < count; i++ {
+		data, err := slip39Interpolate(baseShares, byte(i))
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, slip39RawShare{x: byte(i), data: data})
+	}
+
+	return shares, nil
+}
+
+func slip39RecoverSecret(threshold int, shares []slip39RawShare) ([]byte, error) {
+	if threshold == 1 {
+		return shares[0].data, nil
+	}
+
+	secret, err := slip39Interpolate(shares, slip39SecretIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	digestShare, err := slip39Interpolate(shares, slip39DigestIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := digestShare[:slip39DigestLength]
+	randomPart := digestShare[slip39DigestLength:]
+	if !hmac.Equal(digest, slip39Digest(randomPart, secret)) {
+		return nil, fmt.Errorf("invalid digest of the shared secret")
+	}
+
+	return secret, nil
+}
+
+// slip39Interpolate evaluates at x the Lagrange polynomial over GF(256)
+// defined by the shares.
+func slip39Interpolate(shares []slip39RawShare, x byte) ([]byte, error) {
+	seen := map[byte]bool{}
+	for _, s := range shares {
+		if seen[s.x] {
+			return nil, fmt.Errorf("share indices must be unique")
+		}
+		seen[s.x] = true
+
+		if len(s.data) != len(shares[0].data) {
+			return nil, fmt.Errorf("all share values must have the same length")
+		}
+	}
+
+	for _, s := range shares {
+		if s.x == x {
+			return append([]byte{}, s.data...), nil
+		}
+	}
+
+	logProd := 0
+	for _, s := range shares {
+		logProd += int(slip39Log[s.x^x])
+	}
+
+	result := make([]byte, len(shares[0].data))
+	for _, s := range shares {
+		logBasis := logProd - int(slip39Log[s.x^x])
+		for _, other := range shares {
+			if other.x != s.x {
+				logBasis -= int(slip39Log[s.x^other.x])
+			}
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+
+		for i, v := range s.data {
+			if v != 0 {
+				result[i] ^= slip39Exp[(int(slip39Log[v])+logBasis)%255]
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,197 @@
+package btools
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testdata/slip39_vectors.json holds the official vectors of
+// https://github.com/trezor/python-shamir-mnemonic (vectors.json): a
+// description, the mnemonics, the master secret and the master xprv. The
+// secret is empty for the invalid sets. Every set uses the passphrase TREZOR.
+func TestSLIP39Vectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/slip39_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors [][]json.RawMessage
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range vectors {
+		var description, secret, xprv string
+		var shares []string
+		for i, field := range []any{&description, &shares, &secret, &xprv} {
+			if err := json.Unmarshal(v[i], field); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		mnemonics := make([][]string, len(shares))
+		for i, share := range shares {
+			mnemonics[i] = strings.Fields(share)
+		}
+
+		seed, err := SLIP39Seed(mnemonics, "TREZOR")
+		if secret == "" {
+			if err == nil {
+				t.Errorf("%s: recovered %x from an invalid set", description, seed.Seed)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %v", description, err)
+			continue
+		}
+
+		if got := hex.EncodeToString(seed.Seed); got != secret {
+			t.Errorf("%s: secret %s, want %s", description, got, secret)
+		}
+
+		master, err := MasterPrivateKey(seed)
+		if err != nil {
+			t.Errorf("%s: %v", description, err)
+			continue
+		}
+
+		if got, err := master.SerializeKey(Mainnet); err != nil || got != xprv {
+			t.Errorf("%s: xprv %s, %v, want %s", description, got, err, xprv)
+		}
+	}
+}
+
+func TestSLIP39SplitCombine(t *testing.T) {
+	tests := []struct {
+		name           string
+		secretLength   int
+		groupThreshold int
+		groups         []SLIP39Group
+		extendable     bool
+
+		// shares used to recover the secret, by group and member
+		use [][]int
+	}{
+		{
+			name:           "single share",
+			secretLength:   16,
+			groupThreshold: 1,
+			groups:         []SLIP39Group{{1, 1}},
+			use:            [][]int{{0}},
+		},
+		{
+			name:           "2-of-3",
+			secretLength:   16,
+			groupThreshold: 1,
+			groups:         []SLIP39Group{{2, 3}},
+			use:            [][]int{{2, 0}},
+		},
+		{
+			name:           "2-of-3 extendable 256 bits",
+			secretLength:   32,
+			groupThreshold: 1,
+			groups:         []SLIP39Group{{2, 3}},
+			extendable:     true,
+			use:            [][]int{{1, 2}},
+		},
+		{
+			name:           "2 of 3 groups",
+			secretLength:   16,
+			groupThreshold: 2,
+			groups:         []SLIP39Group{{1, 1}, {2, 3}, {3, 5}},
+			use:            [][]int{nil, {0, 2}, {4, 1, 3}},
+		},
+		{
+			name:           "all groups",
+			secretLength:   32,
+			groupThreshold: 2,
+			groups:         []SLIP39Group{{1, 1}, {2, 2}},
+			use:            [][]int{{0}, {1, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		secret := make([]byte, tt.secretLength)
+		rand.Read(secret)
+
+		shares, err := SLIP39Split(secret, "TREZOR", tt.groupThreshold, tt.groups, tt.extendable, 0)
+		if err != nil {
+			t.Errorf("%s: split: %v", tt.name, err)
+			continue
+		}
+
+		if len(shares) != len(tt.groups) {
+			t.Fatalf("%s: %d groups, want %d", tt.name, len(shares), len(tt.groups))
+		}
+
+		for i, g := range tt.groups {
+			if len(shares[i]) != g.Count {
+				t.Fatalf("%s: group %d has %d shares, want %d", tt.name, i, len(shares[i]), g.Count)
+			}
+		}
+
+		mnemonics := [][]string{}
+		for group, members := range tt.use {
+			for _, member := range members {
+				mnemonics = append(mnemonics, shares[group][member])
+			}
+		}
+
+		got, err := SLIP39Combine(mnemonics, "TREZOR")
+		if err != nil {
+			t.Errorf("%s: combine: %v", tt.name, err)
+			continue
+		}
+
+		if !bytes.Equal(got, secret) {
+			t.Errorf("%s: combined %x, want %x", tt.name, got, secret)
+		}
+
+		// a wrong passphrase is not detected, it gives another secret
+		other, err := SLIP39Combine(mnemonics, "")
+		if err != nil {
+			t.Errorf("%s: combine without passphrase: %v", tt.name, err)
+		} else if bytes.Equal(other, secret) {
+			t.Errorf("%s: the passphrase is ignored", tt.name)
+		}
+
+		// one share less than the threshold of the last group used
+		last := len(mnemonics) - 1
+		if tt.groups[len(tt.groups)-1].Threshold > 1 {
+			if _, err := SLIP39Combine(mnemonics[:last], "TREZOR"); err == nil {
+				t.Errorf("%s: combined fewer shares than the threshold", tt.name)
+			}
+		}
+	}
+}
+
+func TestSLIP39SplitErrors(t *testing.T) {
+	secret := make([]byte, 16)
+
+	tests := []struct {
+		name           string
+		secret         []byte
+		passphrase     string
+		groupThreshold int
+		groups         []SLIP39Group
+	}{
+		{"short secret", secret[:14], "", 1, []SLIP39Group{{1, 1}}},
+		{"odd length", append(secret, 0), "", 1, []SLIP39Group{{1, 1}}},
+		{"group threshold", secret, "", 2, []SLIP39Group{{1, 1}}},
+		{"1-of-n members", secret, "", 1, []SLIP39Group{{1, 2}}},
+		{"non ASCII passphrase", secret, "contraseña", 1, []SLIP39Group{{1, 1}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := SLIP39Split(tt.secret, tt.passphrase, tt.groupThreshold, tt.groups, false, 0); err == nil {
+			t.Errorf("%s: split succeeded", tt.name)
+		}
+	}
+}
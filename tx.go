@@ -0,0 +1,349 @@
+package btools
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// TxHash is a transaction id in internal byte order. It is displayed, and
+// parsed by ParseTxHash, in reverse byte order as usual.
+type TxHash [32]byte
+
+func (h TxHash) String() string {
+	b := h
+	slices.Reverse(b[:])
+
+	return hex.EncodeToString(b[:])
+}
+
+// ParseTxHash parses a transaction id as displayed by String.
+func ParseTxHash(s string) (TxHash, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return TxHash{}, fmt.Errorf("invalid transaction hash: %w", err)
+	}
+
+	if len(b) != 32 {
+		return TxHash{}, fmt.Errorf("invalid transaction hash length: %d", len(b))
+	}
+
+	var h TxHash
+	copy(h[:], b)
+	slices.Reverse(h[:])
+
+	return h, nil
+}
+
+// OutPoint references the output Index of the transaction Hash.
+type OutPoint struct {
+	Hash  TxHash
+	Index uint32
+}
+
+func (op OutPoint) String() string {
+	return fmt.Sprintf("%s:%d", op.Hash, op.Index)
+}
+
+// TxWitness is the witness stack of an input.
+type TxWitness [][]byte
+
+type TxIn struct {
+	PrevOut   OutPoint
+	ScriptSig []byte
+	Sequence  uint32
+	Witness   TxWitness
+}
+
+type TxOut struct {
+	Value        uint64
+	ScriptPubKey []byte
+}
+
+type Tx struct {
+	Version  uint32
+	Inputs   []TxIn
+	Outputs  []TxOut
+	LockTime uint32
+}
+
+// HasWitness tells whether any input has witness data, in which case the
+// transaction is serialized in the SegWit format of BIP144.
+func (tx Tx) HasWitness() bool {
+	for _, in := range tx.Inputs {
+		if len(in.Witness) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Serialize encodes the transaction, in the SegWit format if it has witness
+// data.
+func (tx Tx) Serialize() []byte {
+	return tx.serialize(tx.HasWitness())
+}
+
+// SerializeNoWitness encodes the transaction in the legacy format, which
+// leaves out the witness data.
+func (tx Tx) SerializeNoWitness() []byte {
+	return tx.serialize(false)
+}
+
+func (tx Tx) serialize(withWitness bool) []byte {
+	data := binary.LittleEndian.AppendUint32(nil, tx.Version)
+	if withWitness {
+		// marker and flag
+		data = append(data, 0x00, 0x01)
+	}
+
+	data = appendVarInt(data, uint64(len(tx.Inputs)))
+	for _, in := range tx.Inputs {
+		data = in.PrevOut.append(data)
+		data = appendVarBytes(data, in.ScriptSig)
+		data = binary.LittleEndian.AppendUint32(data, in.Sequence)
+	}
+
+	data = appendVarInt(data, uint64(len(tx.Outputs)))
+	for _, out := range tx.Outputs {
+		data = out.append(data)
+	}
+
+	if withWitness {
+		for _, in := range tx.Inputs {
+			data = in.Witness.append(data)
+		}
+	}
+
+	return binary.LittleEndian.AppendUint32(data, tx.LockTime)
+}
+
+func (op OutPoint) append(b []byte) []byte {
+	b = append(b, op.Hash[:]...)
+	return binary.LittleEndian.AppendUint32(b, op.Index)
+}
+
+func (out TxOut) append(b []byte) []byte {
+	b = binary.LittleEndian.AppendUint64(b, out.Value)
+	return appendVarBytes(b, out.ScriptPubKey)
+}
+
+func (w TxWitness) append(b []byte) []byte {
+	b = appendVarInt(b, uint64(len(w)))
+	for _, item := range w {
+		b = appendVarBytes(b, item)
+	}
+
+	return b
+}
+
+// TxID returns the hash of the transaction without witness data.
+func (tx Tx) TxID() TxHash {
+	return TxHash(doubleSHA256(tx.SerializeNoWitness()))
+}
+
+// WTxID returns the hash of the transaction with witness data, which is the
+// TxID for transactions without it.
+func (tx Tx) WTxID() TxHash {
+	return TxHash(doubleSHA256(tx.Serialize()))
+}
+
+// Weight returns the weight of the transaction as defined in BIP141: three
+// times the size without witness data plus the full size.
+func (tx Tx) Weight() int {
+	return 3*len(tx.SerializeNoWitness()) + len(tx.Serialize())
+}
+
+// VSize returns the virtual size of the transaction, its weight divided by
+// four and rounded up.
+func (tx Tx) VSize() int {
+	return (tx.Weight() + 3) / 4
+}
+
+// ParseTx decodes a transaction in the legacy or the SegWit format.
+func ParseTx(data []byte) (Tx, error) {
+	r := &txReader{data: data}
+
+	var tx Tx
+	tx.Version = r.uint32()
+
+	// a legacy transaction cannot start with zero inputs, so a zero here is
+	// the SegWit marker
+	hasWitness := false
+	if len(r.data) >= 2 && r.data[0] == 0x00 {
+		if r.data[1] != 0x01 {
+			return Tx{}, fmt.Errorf("invalid transaction: unknown SegWit flag %d", r.data[1])
+		}
+
+		hasWitness = true
+		r.data = r.data[2:]
+	}
+
+	tx.Inputs = make([]TxIn, r.count())
+	for i := range tx.Inputs {
+		copy(tx.Inputs[i].PrevOut.Hash[:], r.bytes(32))
+		tx.Inputs[i].PrevOut.Index = r.uint32()
+		tx.Inputs[i].ScriptSig = r.varBytes()
+		tx.Inputs[i].Sequence = r.uint32()
+	}
+
+	tx.Outputs = make([]TxOut, r.count())
+	for i := range tx.Outputs {
+		tx.Outputs[i].Value = r.uint64()
+		tx.Outputs[i].ScriptPubKey = r.varBytes()
+	}
+
+	if hasWitness {
+		for i := range tx.Inputs {
+			tx.Inputs[i].Witness = r.witness()
+		}
+	}
+
+	tx.LockTime = r.uint32()
+
+	if r.err != nil {
+		return Tx{}, fmt.Errorf("invalid transaction: %w", r.err)
+	}
+
+	if len(r.data) != 0 {
+		return Tx{}, fmt.Errorf("invalid transaction: %d bytes of trailing data", len(r.data))
+	}
+
+	if hasWitness && !tx.HasWitness() {
+		return Tx{}, fmt.Errorf("invalid transaction: SegWit format without witness data")
+	}
+
+	return tx, nil
+}
+
+// ParseTxHex decodes a hex encoded transaction.
+func ParseTxHex(s string) (Tx, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return Tx{}, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	return ParseTx(data)
+}
+
+func doubleSHA256(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+
+	return second[:]
+}
+
+func appendVarBytes(b, data []byte) []byte {
+	b = appendVarInt(b, uint64(len(data)))
+	return append(b, data...)
+}
+
+var errShortData = errors.New("unexpected end of data")
+
+// txReader reads the fields of a serialized transaction. The first error
+// is kept in err and every later read returns zero values.
+type txReader struct {
+	data []byte
+	err  error
+}
+
+func (r *txReader) bytes(n uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if uint64(len(r.data)) < n {
+		r.err = errShortData
+		return nil
+	}
+
+	b := r.data[:n]
+	r.data = r.data[n:]
+
+	return b
+}
+
+func (r *txReader) uint32() uint32 {
+	b := r.bytes(4)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(b)
+}
+
+func (r *txReader) uint64() uint64 {
+	b := r.bytes(8)
+	if b == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(b)
+}
+
+// varInt reads a variable length integer, which must use the shortest
+// encoding.
+func (r *txReader) varInt() uint64 {
+	prefix := r.bytes(1)
+	if prefix == nil {
+		return 0
+	}
+
+	var n, minimum uint64
+	switch prefix[0] {
+	case 0xFD:
+		b := r.bytes(2)
+		if b == nil {
+			return 0
+		}
+		n, minimum = uint64(binary.LittleEndian.Uint16(b)), 0xFD
+	case 0xFE:
+		n, minimum = uint64(r.uint32()), 0x10000
+	case 0xFF:
+		n, minimum = r.uint64(), 0x100000000
+	default:
+		return uint64(prefix[0])
+	}
+
+	if r.err == nil && n < minimum {
+		r.err = fmt.Errorf("non-canonical variable length integer")
+	}
+
+	return n
+}
+
+// count reads a number of items, each taking at least one byte, so that a
+// bogus count cannot allocate more than the size of the data.
+func (r *txReader) count() uint64 {
+	n := r.varInt()
+	if r.err == nil && n > uint64(len(r.data)) {
+		r.err = errShortData
+	}
+
+	if r.err != nil {
+		return 0
+	}
+
+	return n
+}
+
+func (r *txReader) varBytes() []byte {
+	return r.bytes(r.varInt())
+}
+
+func (r *txReader) witness() TxWitness {
+	witness := make(TxWitness, r.count())
+	for i := range witness {
+		witness[i] = r.varBytes()
+	}
+
+	if r.err != nil {
+		return nil
+	}
+
+	return witness
+}
@@ -0,0 +1,190 @@
+package btools
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+type txVector struct {
+	Description string `json:"description"`
+	Hex         string `json:"hex"`
+	TxID        string `json:"txid"`
+	WTxID       string `json:"wtxid"`
+	Weight      int    `json:"weight"`
+	VSize       int    `json:"vsize"`
+}
+
+// testdata/tx_vectors.json holds mainnet legacy transactions (the examples of
+// BIP69 and transactions of blocks 100001, 100998 and 277647) and the signed
+// SegWit examples of BIP143.
+func readTxVectors(t *testing.T) []txVector {
+	data, err := os.ReadFile("testdata/tx_vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var vectors []txVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+
+	return vectors
+}
+
+func TestTxVectors(t *testing.T) {
+	for _, v := range readTxVectors(t) {
+		tx, err := ParseTxHex(v.Hex)
+		if err != nil {
+			t.Errorf("%s: %v", v.Description, err)
+			continue
+		}
+
+		if got := hex.EncodeToString(tx.Serialize()); got != v.Hex {
+			t.Errorf("%s: serialized as %s", v.Description, got)
+		}
+
+		if got := tx.TxID().String(); got != v.TxID {
+			t.Errorf("%s: txid %s, want %s", v.Description, got, v.TxID)
+		}
+
+		if got := tx.WTxID().String(); got != v.WTxID {
+			t.Errorf("%s: wtxid %s, want %s", v.Description, got, v.WTxID)
+		}
+
+		if tx.Weight() != v.Weight || tx.VSize() != v.VSize {
+			t.Errorf("%s: weight %d and vsize %d, want %d and %d", v.Description, tx.Weight(), tx.VSize(), v.Weight, v.VSize)
+		}
+
+		if tx.HasWitness() != (v.TxID != v.WTxID) {
+			t.Errorf("%s: HasWitness() = %v", v.Description, tx.HasWitness())
+		}
+
+		hash, err := ParseTxHash(v.TxID)
+		if err != nil || hash != tx.TxID() {
+			t.Errorf("%s: ParseTxHash(%s) = %s, %v", v.Description, v.TxID, hash, err)
+		}
+
+		// without the witness the txid is kept and every byte weighs 4
+		stripped, err := ParseTx(tx.SerializeNoWitness())
+		if err != nil {
+			t.Errorf("%s: stripped: %v", v.Description, err)
+			continue
+		}
+
+		if stripped.HasWitness() || stripped.TxID() != tx.TxID() || stripped.WTxID() != tx.TxID() {
+			t.Errorf("%s: stripped transaction %s", v.Description, stripped.WTxID())
+		}
+
+		if stripped.Weight() != 4*len(tx.SerializeNoWitness()) {
+			t.Errorf("%s: stripped weight %d", v.Description, stripped.Weight())
+		}
+	}
+}
+
+func TestVarInt(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{0, "00"},
+		{0xFC, "fc"},
+		{0xFD, "fdfd00"},
+		{0xFFFF, "fdffff"},
+		{0x10000, "fe00000100"},
+		{0xFFFFFFFF, "feffffffff"},
+		{0x100000000, "ff0000000001000000"},
+		{0xFFFFFFFFFFFFFFFF, "ffffffffffffffffff"},
+	}
+
+	for _, tt := range tests {
+		if got := hex.EncodeToString(appendVarInt(nil, tt.n)); got != tt.want {
+			t.Errorf("appendVarInt(%#x) = %s, want %s", tt.n, got, tt.want)
+		}
+
+		data, _ := hex.DecodeString(tt.want)
+		r := &txReader{data: data}
+		if got := r.varInt(); r.err != nil || got != tt.n || len(r.data) != 0 {
+			t.Errorf("varInt(%s) = %#x, %v", tt.want, got, r.err)
+		}
+	}
+
+	nonCanonical := []string{
+		"fd0000",
+		"fdfc00",
+		"feffff0000",
+		"fe00000000",
+		"ffffffffff00000000",
+		"ff0000000000000000",
+	}
+
+	for _, s := range nonCanonical {
+		data, _ := hex.DecodeString(s)
+		r := &txReader{data: data}
+		if r.varInt(); r.err == nil {
+			t.Errorf("varInt(%s) accepted a non-canonical encoding", s)
+		}
+	}
+
+	for _, s := range []string{"", "fd", "fdff", "feffffff", "ffffffffffffffff"} {
+		data, _ := hex.DecodeString(s)
+		r := &txReader{data: data}
+		if r.varInt(); r.err == nil {
+			t.Errorf("varInt(%s) accepted truncated data", s)
+		}
+	}
+}
+
+func TestParseTxMalformed(t *testing.T) {
+	vectors := readTxVectors(t)
+
+	var legacy, segwit []byte
+	for _, v := range vectors {
+		raw, _ := hex.DecodeString(v.Hex)
+		if v.TxID == v.WTxID && legacy == nil {
+			legacy = raw
+		}
+		if v.TxID != v.WTxID && segwit == nil {
+			segwit = raw
+		}
+	}
+
+	for _, raw := range [][]byte{legacy, segwit} {
+		for i := range raw {
+			if _, err := ParseTx(raw[:i]); err == nil {
+				t.Errorf("ParseTx accepted %d of %d bytes", i, len(raw))
+			}
+		}
+
+		if _, err := ParseTx(append(bytes.Clone(raw), 0)); err == nil {
+			t.Errorf("ParseTx accepted trailing data")
+		}
+	}
+
+	// the input count of the legacy transaction as fd + 2 bytes
+	count := legacy[4]
+	nonCanonical := append(bytes.Clone(legacy[:4]), 0xFD, count, 0)
+	nonCanonical = append(nonCanonical, legacy[5:]...)
+	if _, err := ParseTx(nonCanonical); err == nil {
+		t.Errorf("ParseTx accepted a non-canonical input count")
+	}
+
+	tests := []struct {
+		name string
+		tx   string
+	}{
+		{"unknown flag", "0100000000020000000000"},
+		{"huge input count", "01000000ffffffffffffffffff"},
+		{"huge script length", "0100000001" + strings.Repeat("00", 36) + "feffffff7f"},
+		{"empty witness", "01000000000101" + strings.Repeat("00", 36) + "00ffffffff01000000000000000000" + "00" + "00000000"},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseTxHex(tt.tx); err == nil {
+			t.Errorf("%s: ParseTx succeeded", tt.name)
+		}
+	}
+}
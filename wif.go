@@ -0,0 +1,82 @@
+package btools
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// WIF is a private key in Wallet Import Format.
+type WIF struct {
+	PrivateKey *big.Int
+
+	// Compressed tells whether the key is used with its compressed public
+	// key.
+	Compressed bool
+
+	// Network is the first registered network using the WIF prefix, e.g.
+	// Testnet3 for every test network.
+	Network Network
+}
+
+// EncodeWIF serializes the private key k in Wallet Import Format with the
+// prefix of net.
+func EncodeWIF(k *big.Int, compressed bool, net Network) (string, error) {
+	if k == nil || k.Sign() <= 0 || k.Cmp(secp256k1Order) >= 0 {
+		return "", fmt.Errorf("invalid private key: out of range")
+	}
+
+	payload := append([]byte{net.WIFPrefix}, k.FillBytes(make([]byte, 32))...)
+	if compressed {
+		payload = append(payload, 0x01)
+	}
+
+	return Base58Check(payload), nil
+}
+
+// DecodeWIF parses a private key in Wallet Import Format.
+func DecodeWIF(s string) (WIF, error) {
+	data, err := Base58CheckDecode(s)
+	if err != nil {
+		return WIF{}, err
+	}
+
+	compressed := false
+	switch {
+	case len(data) == 34 && data[33] == 0x01:
+		compressed = true
+	case len(data) == 34:
+		return WIF{}, fmt.Errorf("invalid WIF compression flag: %#02x", data[33])
+	case len(data) != 33:
+		return WIF{}, fmt.Errorf("invalid WIF length: %d bytes", len(data))
+	}
+
+	var net Network
+	found := false
+	for _, n := range Networks {
+		if n.WIFPrefix == data[0] {
+			net = n
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return WIF{}, fmt.Errorf("%w: WIF prefix %#02x", ErrUnknownNetwork, data[0])
+	}
+
+	k := big.NewInt(0).SetBytes(data[1:33])
+	if k.Sign() == 0 || k.Cmp(secp256k1Order) >= 0 {
+		return WIF{}, fmt.Errorf("invalid private key: out of range")
+	}
+
+	return WIF{
+		PrivateKey: k,
+		Compressed: compressed,
+		Network:    net,
+	}, nil
+}
+
+// WIF returns the private key of xpriv in compressed Wallet Import Format.
+func (xpriv XPrivKey) WIF(net Network) (string, error) {
+	return EncodeWIF(xpriv.PrivateKey, true, net)
+}
@@ -0,0 +1,78 @@
+package btools
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestWIF(t *testing.T) {
+	k := hexInt("0c28fca386c7a227600b2fe50b7cae11ec86d3bf1fbe471be89827e19d72aa1d")
+
+	tests := []struct {
+		compressed bool
+		net        Network
+		wif        string
+	}{
+		{false, Mainnet, "5HueCGU8rMjxEXxiPuD5BDku4MkFqeZyd4dZ1jvhTVqvbTLvyTJ"},
+		{true, Mainnet, "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98617"},
+		{false, Testnet3, "91gGn1HgSap6CbU12F6z3pJri26xzp7Ay1VW6NHCoEayNXwRpu2"},
+		{true, Testnet3, "cMzLdeGd5vEqxB8B6VFQoRopQ3sLAAvEzDAoQgvX54xwofSWj1fx"},
+	}
+
+	for _, tt := range tests {
+		got, err := EncodeWIF(k, tt.compressed, tt.net)
+		if err != nil || got != tt.wif {
+			t.Errorf("EncodeWIF(%v, %s) = %s, %v, want %s", tt.compressed, tt.net.Name, got, err, tt.wif)
+		}
+
+		wif, err := DecodeWIF(tt.wif)
+		if err != nil {
+			t.Errorf("DecodeWIF(%s): %v", tt.wif, err)
+			continue
+		}
+
+		if wif.PrivateKey.Cmp(k) != 0 || wif.Compressed != tt.compressed || wif.Network.Name != tt.net.Name {
+			t.Errorf("DecodeWIF(%s) = %x, %v, %s", tt.wif, wif.PrivateKey, wif.Compressed, wif.Network.Name)
+		}
+	}
+
+	// the key of the BIP322 vectors and its P2WPKH address
+	wif, err := DecodeWIF("L3VFeEujGtevx9w18HD1fhRbCH67Az2dpCymeRE1SoPK6XQtaN2k")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if address, err := Address(Secp256k1Pub(wif.PrivateKey), AddressP2WPKH, wif.Network); err != nil || address != "bc1q9vza2e8x573nczrlzms0wvx3gsqjx7vavgkx0l" {
+		t.Errorf("address of the BIP322 key = %s, %v", address, err)
+	}
+}
+
+func TestWIFErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		wif  string
+	}{
+		{"bad compression flag", "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvWxyf5d"},
+		{"short key", "yPoVP5njSzmEVK4VJGRWWAwqnwCyLPRcMm5XyrKgY1DE64xhu"},
+		{"unknown prefix", "AmgFiivzoKk4NeqHRNxv5KSZLt7Ujn1UpnQFc6z98Hap6Pyg8Dbp"},
+		{"zero key", "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73Nd2Mcv1"},
+		{"bad checksum", "KwdMAjGmerYanjeui5SHS7JkmpZvVipYvB2LJGU1ZxJwYvP98618"},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeWIF(tt.wif); err == nil {
+			t.Errorf("%s: DecodeWIF(%s) succeeded", tt.name, tt.wif)
+		}
+	}
+
+	if _, err := DecodeWIF("AmgFiivzoKk4NeqHRNxv5KSZLt7Ujn1UpnQFc6z98Hap6Pyg8Dbp"); !errors.Is(err, ErrUnknownNetwork) {
+		t.Errorf("DecodeWIF of an unknown prefix returned %v, want ErrUnknownNetwork", err)
+	}
+
+	for _, k := range []*big.Int{nil, big.NewInt(0), secp256k1Order} {
+		if _, err := EncodeWIF(k, true, Mainnet); err == nil {
+			t.Errorf("EncodeWIF(%v) succeeded", k)
+		}
+	}
+}